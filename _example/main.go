@@ -8,6 +8,9 @@ import (
 	"sync/atomic"
 	"time"
 
+	_ "github.com/boz/ephemerald/builtin/migrate"
+	_ "github.com/boz/ephemerald/builtin/mongo"
+	_ "github.com/boz/ephemerald/builtin/mysql"
 	_ "github.com/boz/ephemerald/builtin/postgres"
 	_ "github.com/boz/ephemerald/builtin/redis"
 	_ "github.com/lib/pq"
@@ -13,6 +13,7 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/registry"
@@ -23,12 +24,16 @@ type dockerAdapter interface {
 	imageReference() reference.Named
 	ensureImage() error
 	createContainer() (string, error)
+	listContainers() ([]types.Container, error)
 	containerStart(id string, options types.ContainerStartOptions) error
 
 	containerInspect(id string) (types.ContainerJSON, error)
 	containerKill(id string, signal string) error
 	containerEvents(options types.EventsOptions) (<-chan events.Message, <-chan error)
 	containerLogs(id string, options types.ContainerLogsOptions) (io.ReadCloser, error)
+	containerExec(ctx context.Context, id string, cmd []string, env []string, stdout, stderr io.Writer) (int, error)
+	containerCopyTo(ctx context.Context, id string, dest string, content io.Reader) error
+	containerInspectWith(ctx context.Context, id string) (types.ContainerJSON, error)
 
 	makeParams(StatusItem) (params.Params, error)
 
@@ -144,24 +149,40 @@ func (a *dadapter) imagePull() error {
 
 func (a *dadapter) createContainer() (string, error) {
 
+	cconfig := a.config.Container
+
 	dconfig := &container.Config{
 		Image:        a.ref.Name(),
-		Cmd:          a.config.Container.Cmd,
-		Env:          a.config.Container.Env,
-		Volumes:      a.config.Container.Volumes,
-		Labels:       a.config.Container.Labels,
+		Cmd:          cconfig.Cmd,
+		Env:          cconfig.Env,
+		Volumes:      cconfig.Volumes,
+		Entrypoint:   cconfig.Entrypoint,
+		User:         cconfig.User,
+		Labels:       a.containerLabels(),
 		AttachStdin:  false,
 		AttachStdout: false,
 		AttachStderr: false,
-		ExposedPorts: nat.PortSet{
-			nat.Port(strconv.Itoa(a.config.Port)): struct{}{},
-		},
+		ExposedPorts: a.exposedPorts(),
 	}
 
 	hconfig := &container.HostConfig{
 		AutoRemove:      true,
 		PublishAllPorts: true,
 		RestartPolicy:   container.RestartPolicy{},
+		CapAdd:          cconfig.CapAdd,
+		CapDrop:         cconfig.CapDrop,
+		Binds:           cconfig.Binds,
+		Tmpfs:           cconfig.Tmpfs,
+		ShmSize:         int64(cconfig.ShmSize),
+		Sysctls:         cconfig.Sysctls,
+		Privileged:      cconfig.Privileged,
+		ExtraHosts:      cconfig.ExtraHosts,
+		Resources: container.Resources{
+			Memory:    int64(cconfig.Memory),
+			NanoCPUs:  cconfig.NanoCPUs(),
+			CPUShares: cconfig.CPUShares,
+			Ulimits:   cconfig.DockerUlimits(),
+		},
 	}
 
 	nconfig := &network.NetworkingConfig{}
@@ -182,6 +203,40 @@ func (a *dadapter) createContainer() (string, error) {
 	return container.ID, nil
 }
 
+func (a *dadapter) exposedPorts() nat.PortSet {
+	ports := nat.PortSet{
+		nat.Port(strconv.Itoa(a.config.Port)): struct{}{},
+	}
+	for _, port := range a.config.Ports {
+		ports[nat.Port(strconv.Itoa(port))] = struct{}{}
+	}
+	return ports
+}
+
+// containerLabels returns the configured labels along with
+// those identifying the pool that owns the container.
+func (a *dadapter) containerLabels() map[string]string {
+	labels := make(map[string]string)
+	for k, v := range a.config.Container.Labels {
+		labels[k] = v
+	}
+	labels[labelPool] = a.config.Name
+	labels[labelConfig] = a.config.Hash
+	labels[labelInstance] = instanceID
+	labels[labelHost] = instanceHost
+	labels[labelPID] = strconv.Itoa(instancePID)
+	return labels
+}
+
+// listContainers returns the running containers that were
+// created for this pool by any ephemerald process.
+func (a *dadapter) listContainers() ([]types.Container, error) {
+	f := filters.NewArgs()
+	f.Add("label", labelPool+"="+a.config.Name)
+
+	return a.client.ContainerList(a.ctx, types.ContainerListOptions{Filters: f})
+}
+
 func (a *dadapter) containerStart(id string, options types.ContainerStartOptions) error {
 	return a.client.ContainerStart(a.ctx, id, options)
 }
@@ -202,8 +257,63 @@ func (a *dadapter) containerLogs(id string, options types.ContainerLogsOptions)
 	return a.client.ContainerLogs(a.ctx, id, options)
 }
 
+// containerExec runs cmd in the container and waits for it to complete.
+func (a *dadapter) containerExec(ctx context.Context, id string, cmd []string, env []string, stdout, stderr io.Writer) (int, error) {
+	config := types.ExecConfig{
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          env,
+		Cmd:          cmd,
+	}
+
+	exec, err := a.client.ContainerExecCreate(ctx, id, config)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := a.client.ContainerExecAttach(ctx, exec.ID, config)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Close()
+
+	donech := make(chan bool)
+	defer close(donech)
+
+	// unblock the read when ctx is cancelled.
+	go func() {
+		select {
+		case <-ctx.Done():
+			resp.Close()
+		case <-donech:
+		}
+	}()
+
+	if err := demuxStreams(stdout, stderr, resp.Reader); err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, err
+	}
+
+	status, err := a.client.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return status.ExitCode, nil
+}
+
+func (a *dadapter) containerInspectWith(ctx context.Context, id string) (types.ContainerJSON, error) {
+	return a.client.ContainerInspect(ctx, id)
+}
+
+func (a *dadapter) containerCopyTo(ctx context.Context, id string, dest string, content io.Reader) error {
+	return a.client.CopyToContainer(ctx, id, dest, content, types.CopyToContainerOptions{})
+}
+
 func (a *dadapter) makeParams(c StatusItem) (params.Params, error) {
-	return a.config.Params.ParamsFor(c.ID(), c.Status(), a.config.Port)
+	return a.config.Params.ParamsFor(c.ID(), c.Status(), a.config.Port, a.config.Ports)
 }
 
 func (a *dadapter) logger() logrus.FieldLogger {
@@ -1,66 +1,179 @@
 package ephemerald
 
-import "github.com/boz/ephemerald/ui"
+import (
+	"context"
+	"time"
+
+	"github.com/boz/ephemerald/ui"
+)
 
 type poolItemBuffer interface {
-	get() <-chan poolItem
+	get(ctx context.Context) (poolItem, error)
 	put(c poolItem)
+	removeIdle(max int, idle time.Duration) []poolItem
 	stop()
 }
 
+type pibufferEntry struct {
+	item    poolItem
+	readyAt time.Time
+}
+
+type pibufferIdleRequest struct {
+	max  int
+	idle time.Duration
+	ch   chan []poolItem
+}
+
+// a client waiting for an item.  ch is buffered so that
+// the buffer never blocks when handing out an item.  the
+// whole entry is handed out so that it can be put back
+// unchanged if the client gives up.
+type pibufferWaiter struct {
+	ch chan pibufferEntry
+}
+
 type pibuffer struct {
-	outch chan poolItem
-	inch  chan poolItem
-	buf   []poolItem
+	inch     chan poolItem
+	waitch   chan *pibufferWaiter
+	cancelch chan *pibufferWaiter
+	idlech   chan pibufferIdleRequest
+
+	buf []pibufferEntry
+
+	// waiting clients, in order of arrival.
+	waiters []*pibufferWaiter
+
+	// closed when stopped
+	donech chan bool
 
 	uie ui.PoolEmitter
 }
 
 func newPoolItemBuffer(uie ui.PoolEmitter) poolItemBuffer {
 	b := &pibuffer{
-		outch: make(chan poolItem),
-		inch:  make(chan poolItem),
-		uie:   uie,
+		inch:     make(chan poolItem),
+		waitch:   make(chan *pibufferWaiter),
+		cancelch: make(chan *pibufferWaiter),
+		idlech:   make(chan pibufferIdleRequest),
+		donech:   make(chan bool),
+		uie:      uie,
 	}
 	go b.run()
 	return b
 }
 
-func (b *pibuffer) get() <-chan poolItem {
-	return b.outch
+// get waits for the next ready item.  clients are served
+// in the order that they call get.
+func (b *pibuffer) get(ctx context.Context) (poolItem, error) {
+	w := &pibufferWaiter{make(chan pibufferEntry, 1)}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-b.donech:
+		return nil, errNotRunning
+	case b.waitch <- w:
+	}
+
+	select {
+	case entry, ok := <-w.ch:
+		if !ok {
+			return nil, errNotRunning
+		}
+		return entry.item, nil
+	case <-ctx.Done():
+		// an item handed out after ctx expired is reclaimed by the buffer.
+		select {
+		case <-b.donech:
+		case b.cancelch <- w:
+		}
+		return nil, ctx.Err()
+	}
 }
 
 func (b *pibuffer) put(c poolItem) {
 	b.inch <- c
 }
 
+// removeIdle takes up to max items that have been ready
+// for longer than idle out of the buffer.
+func (b *pibuffer) removeIdle(max int, idle time.Duration) []poolItem {
+	ch := make(chan []poolItem, 1)
+	b.idlech <- pibufferIdleRequest{max, idle, ch}
+	return <-ch
+}
+
 func (b *pibuffer) stop() {
 	close(b.inch)
 }
 
 func (b *pibuffer) run() {
-	defer close(b.outch)
+	defer close(b.donech)
 	for {
 
-		b.uie.EmitNumReady(len(b.buf))
-
-		var next poolItem
-		var out chan poolItem
+		b.serveWaiters()
 
-		if len(b.buf) > 0 {
-			next = b.buf[0]
-			out = b.outch
-		}
+		b.uie.EmitNumReady(len(b.buf))
 
 		select {
 		case c, ok := <-b.inch:
 			if !ok {
 				b.uie.EmitNumReady(0)
+				b.closeWaiters()
 				return
 			}
-			b.buf = append(b.buf, c)
-		case out <- next:
-			b.buf = b.buf[1:]
+			b.buf = append(b.buf, pibufferEntry{c, time.Now()})
+		case w := <-b.waitch:
+			b.waiters = append(b.waiters, w)
+		case w := <-b.cancelch:
+			b.cancelWaiter(w)
+		case req := <-b.idlech:
+			req.ch <- b.takeIdle(req.max, req.idle)
+		}
+	}
+}
+
+func (b *pibuffer) serveWaiters() {
+	for len(b.waiters) > 0 && len(b.buf) > 0 {
+		b.waiters[0].ch <- b.buf[0]
+		b.waiters = b.waiters[1:]
+		b.buf = b.buf[1:]
+	}
+}
+
+func (b *pibuffer) cancelWaiter(w *pibufferWaiter) {
+	for idx, cur := range b.waiters {
+		if cur == w {
+			b.waiters = append(b.waiters[:idx], b.waiters[idx+1:]...)
+			return
 		}
 	}
+
+	// already served; put the item back at the front of the line.
+	select {
+	case entry := <-w.ch:
+		b.buf = append([]pibufferEntry{entry}, b.buf...)
+	default:
+	}
+}
+
+func (b *pibuffer) closeWaiters() {
+	for _, w := range b.waiters {
+		close(w.ch)
+	}
+	b.waiters = nil
+}
+
+func (b *pibuffer) takeIdle(max int, idle time.Duration) []poolItem {
+	var items []poolItem
+
+	// entries are ordered by the time they became ready.
+	cutoff := time.Now().Add(-idle)
+	for len(items) < max && len(b.buf) > 0 && b.buf[0].readyAt.Before(cutoff) {
+		items = append(items, b.buf[0].item)
+		b.buf = b.buf[1:]
+	}
+
+	return items
 }
@@ -0,0 +1,110 @@
+package ephemerald
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/ui"
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuffer_fifo(t *testing.T) {
+	b := newPoolItemBuffer(ui.NewNoopEmitter().ForPool("test"))
+	defer b.stop()
+
+	ctx := context.Background()
+
+	results := make([]chan poolItem, 3)
+	for idx := range results {
+		results[idx] = make(chan poolItem, 1)
+		go func(ch chan poolItem) {
+			item, err := b.get(ctx)
+			assert.NoError(t, err)
+			ch <- item
+		}(results[idx])
+
+		// let each waiter queue up before the next.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		b.put(testItem(id))
+	}
+
+	for idx, id := range []string{"a", "b", "c"} {
+		select {
+		case item := <-results[idx]:
+			assert.Equal(t, id, item.ID())
+		case <-time.After(time.Second):
+			require.Fail(t, "timed out waiting for item")
+		}
+	}
+}
+
+func TestBuffer_timeout(t *testing.T) {
+	b := newPoolItemBuffer(ui.NewNoopEmitter().ForPool("test"))
+	defer b.stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := b.get(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	b.put(testItem("a"))
+
+	item, err := b.get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "a", item.ID())
+}
+
+func TestBuffer_cancelServed(t *testing.T) {
+	readyAt := time.Now().Add(-time.Minute)
+
+	b := &pibuffer{}
+	w := &pibufferWaiter{make(chan pibufferEntry, 1)}
+	w.ch <- pibufferEntry{testItem("a"), readyAt}
+
+	b.cancelWaiter(w)
+
+	require.Len(t, b.buf, 1)
+	assert.Equal(t, "a", b.buf[0].item.ID())
+	assert.Equal(t, readyAt, b.buf[0].readyAt)
+}
+
+func TestBuffer_stop(t *testing.T) {
+	b := newPoolItemBuffer(ui.NewNoopEmitter().ForPool("test"))
+
+	errch := make(chan error, 1)
+	go func() {
+		_, err := b.get(context.Background())
+		errch <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	b.stop()
+
+	select {
+	case err := <-errch:
+		assert.Equal(t, errNotRunning, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "timed out waiting for stop")
+	}
+}
+
+type testItem string
+
+func (i testItem) ID() string                          { return string(i) }
+func (i testItem) Status() types.ContainerJSON         { return types.ContainerJSON{} }
+func (i testItem) join(ch chan<- poolEvent)            {}
+func (i testItem) start()                              {}
+func (i testItem) reset()                              {}
+func (i testItem) kill()                               {}
+func (i testItem) logs() *logBuffer                    { return newLogBuffer(0) }
+func (i testItem) checkout(context.Context) error      { return nil }
+func (i testItem) actions() lifecycle.ContainerManager { return nil }
+func (i testItem) done() <-chan bool                   { return nil }
@@ -0,0 +1,5 @@
+create table users (
+  id serial primary key,
+  name varchar(255),
+  unique(name)
+);
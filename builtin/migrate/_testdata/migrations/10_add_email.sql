@@ -0,0 +1 @@
+alter table users add column email varchar(255);
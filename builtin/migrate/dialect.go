@@ -0,0 +1,35 @@
+package migrate
+
+import (
+	"database/sql"
+
+	"github.com/boz/ephemerald/builtin/mysql"
+	"github.com/boz/ephemerald/builtin/postgres"
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/lib/pq"
+)
+
+// dialect holds the differences between the supported databases.
+type dialect struct {
+	open        func(lifecycle.Env, params.Params) (*sql.DB, error)
+	quote       func(string) string
+	placeholder string
+}
+
+var dialects = map[string]*dialect{
+	"postgres": {
+		open:        postgres.OpenDB,
+		quote:       pq.QuoteIdentifier,
+		placeholder: "$1",
+	},
+	"mysql": {
+		open:        mysql.OpenDB,
+		quote:       mysql.QuoteIdentifier,
+		placeholder: "?",
+	},
+}
+
+func findDialect(driver string) *dialect {
+	return dialects[driver]
+}
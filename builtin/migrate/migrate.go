@@ -0,0 +1,180 @@
+package migrate
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+)
+
+const (
+	defaultRetries = 3
+	defaultTimeout = 30 * time.Second
+	defaultDelay   = lifecycle.ActionDefaultDelay
+	defaultDriver  = "postgres"
+	defaultTable   = "schema_migrations"
+)
+
+func init() {
+	lifecycle.MakeActionPlugin("sql.migrate", actionMigrateParse)
+}
+
+func actionMigrateParse(buf []byte) (lifecycle.Action, error) {
+	action := &actionMigrate{
+		ActionConfig: lifecycle.ActionConfig{
+			Retries: defaultRetries,
+			Timeout: defaultTimeout,
+			Delay:   defaultDelay,
+		},
+		Driver: defaultDriver,
+		Table:  defaultTable,
+	}
+
+	err := json.Unmarshal(buf, action)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		key string
+		val *string
+	}{
+		{"dir", &action.Dir},
+		{"driver", &action.Driver},
+		{"table", &action.Table},
+	} {
+		val, err := jsonparser.GetString(buf, field.key)
+		switch {
+		case err == nil:
+			*field.val = val
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	if action.Dir == "" {
+		return nil, fmt.Errorf("sql.migrate: dir required")
+	}
+
+	if action.Table == "" {
+		return nil, fmt.Errorf("sql.migrate: table required")
+	}
+
+	if action.dialect = findDialect(action.Driver); action.dialect == nil {
+		return nil, fmt.Errorf("sql.migrate: unknown driver '%v'", action.Driver)
+	}
+
+	return action, nil
+}
+
+// actionMigrate applies the up-migrations in Dir that haven't
+// been recorded in Table.
+type actionMigrate struct {
+	lifecycle.ActionConfig
+
+	// directory containing the migration files.
+	Dir string
+
+	// database driver: "postgres" or "mysql".
+	Driver string
+
+	// table that applied versions are recorded in.
+	Table string
+
+	dialect *dialect
+}
+
+func (a *actionMigrate) Do(e lifecycle.Env, p params.Params) error {
+	migrations, err := readMigrations(e.Path(a.Dir))
+	if err != nil {
+		e.Log().WithError(err).Error("reading migrations")
+		return err
+	}
+
+	db, err := a.dialect.open(e, p)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	table := a.dialect.quote(a.Table)
+
+	_, err = db.ExecContext(e.Context(),
+		"CREATE TABLE IF NOT EXISTS "+table+" (version VARCHAR(255) PRIMARY KEY)")
+	if err != nil {
+		e.Log().WithError(err).Debug("ERROR: create migrations table")
+		return err
+	}
+
+	applied, err := a.appliedVersions(e, db, table)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+
+		e.Step(m.name)
+
+		if err := a.apply(e, db, table, m); err != nil {
+			e.Log().WithError(err).WithField("migration", m.name).Debug("ERROR: apply")
+			return fmt.Errorf("%v: %v", m.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (a *actionMigrate) appliedVersions(e lifecycle.Env, db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.QueryContext(e.Context(), "SELECT version FROM "+table)
+	if err != nil {
+		e.Log().WithError(err).Debug("ERROR: select versions")
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			e.Log().WithError(err).Debug("ERROR: scan")
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// apply runs the migration and records its version in a single transaction.
+func (a *actionMigrate) apply(e lifecycle.Env, db *sql.DB, table string, m migration) error {
+	body, err := ioutil.ReadFile(m.path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(e.Context(), nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(e.Context(), string(body)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := "INSERT INTO " + table + " (version) VALUES (" + a.dialect.placeholder + ")"
+	if _, err := tx.ExecContext(e.Context(), query, m.version); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,30 @@
+package migrate_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/boz/ephemerald/params"
+	"github.com/boz/ephemerald/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionMigrate(t *testing.T) {
+	files := []string{"pool.json", "pool.yaml"}
+
+	for _, file := range files {
+		testutil.RunPoolFromFile(t, file, func(p params.Params) {
+			db, err := sql.Open("postgres", p.Url)
+			require.NoError(t, err, file)
+			defer db.Close()
+
+			var count int
+			require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count), file)
+			require.Equal(t, 3, count, file)
+
+			var name string
+			require.NoError(t, db.QueryRow("SELECT name FROM users WHERE email IS NULL").Scan(&name), file)
+			require.Equal(t, "seed", name, file)
+		})
+	}
+}
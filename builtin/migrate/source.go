@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	migrationExt     = ".sql"
+	downMigrationExt = ".down.sql"
+)
+
+type migration struct {
+	version string
+	number  uint64
+	name    string
+	path    string
+}
+
+// readMigrations returns the up-migrations in dir ordered by version.
+//
+// Migration files are named with a numeric version prefix, for example
+// "0001_create_users.sql" or "20170501120000_add_index.up.sql".  Files
+// ending in ".down.sql" and files without a version are ignored.
+func readMigrations(dir string) ([]migration, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []migration
+	seen := make(map[uint64]string)
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if entry.IsDir() ||
+			!strings.HasSuffix(name, migrationExt) ||
+			strings.HasSuffix(name, downMigrationExt) {
+			continue
+		}
+
+		digits := len(name) - len(strings.TrimLeft(name, "0123456789"))
+		if digits == 0 {
+			continue
+		}
+
+		number, err := strconv.ParseUint(name[:digits], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("sql.migrate: %v: %v", name, err)
+		}
+
+		if other, ok := seen[number]; ok {
+			return nil, fmt.Errorf("sql.migrate: %v and %v have the same version", other, name)
+		}
+		seen[number] = name
+
+		migrations = append(migrations, migration{
+			version: strconv.FormatUint(number, 10),
+			number:  number,
+			name:    name,
+			path:    filepath.Join(dir, name),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].number < migrations[j].number
+	})
+
+	return migrations, nil
+}
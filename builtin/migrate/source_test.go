@@ -0,0 +1,41 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMigrations(t *testing.T) {
+	migrations, err := readMigrations("_testdata/migrations")
+	require.NoError(t, err)
+
+	var names, versions []string
+	for _, m := range migrations {
+		names = append(names, m.name)
+		versions = append(versions, m.version)
+	}
+
+	assert.Equal(t, []string{"0001_create_users.sql", "0002_seed_users.sql", "10_add_email.sql"}, names)
+	assert.Equal(t, []string{"1", "2", "10"}, versions)
+}
+
+func TestReadMigrations_duplicate(t *testing.T) {
+	_, err := readMigrations("_testdata/duplicate")
+	assert.Error(t, err)
+}
+
+func TestActionMigrateParse(t *testing.T) {
+	_, err := actionMigrateParse([]byte(`{"type":"sql.migrate"}`))
+	assert.Error(t, err, "missing dir")
+
+	_, err = actionMigrateParse([]byte(`{"type":"sql.migrate","dir":"x","driver":"sqlite"}`))
+	assert.Error(t, err, "unknown driver")
+
+	action, err := actionMigrateParse([]byte(`{"type":"sql.migrate","dir":"x","driver":"mysql","table":"versions"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "x", action.(*actionMigrate).Dir)
+	assert.Equal(t, "versions", action.(*actionMigrate).Table)
+	assert.Equal(t, "?", action.(*actionMigrate).dialect.placeholder)
+}
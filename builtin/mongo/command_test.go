@@ -0,0 +1,43 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/buger/jsonparser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestParseCommand(t *testing.T) {
+	expected := bson.D{
+		{Name: "createIndexes", Value: "users"},
+		{Name: "indexes", Value: []interface{}{
+			bson.D{
+				{Name: "key", Value: bson.D{{Name: "name", Value: 1}}},
+				{Name: "name", Value: "name_1"},
+				{Name: "unique", Value: true},
+			},
+		}},
+	}
+
+	for _, buf := range []string{
+		`{"createIndexes": "users", "indexes": [{"key": {"name": 1}, "name": "name_1", "unique": true}]}`,
+		`[{"createIndexes": "users"}, {"indexes": [{"key": {"name": 1}, "name": "name_1", "unique": true}]}]`,
+	} {
+		val, vt, _, err := jsonparser.Get([]byte(buf))
+		require.NoError(t, err, buf)
+
+		cmd, err := parseCommand(val, vt)
+		require.NoError(t, err, buf)
+		assert.Equal(t, expected, cmd, buf)
+	}
+
+	for _, buf := range []string{`{}`, `[]`, `"ping"`, `[1]`} {
+		val, vt, _, err := jsonparser.Get([]byte(buf))
+		require.NoError(t, err, buf)
+
+		_, err = parseCommand(val, vt)
+		assert.Error(t, err, buf)
+	}
+}
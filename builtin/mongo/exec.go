@@ -0,0 +1,208 @@
+package mongo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	defaultRetries = 10
+	defaultTimeout = lifecycle.ActionDefaultTimeout
+	defaultDelay   = lifecycle.ActionDefaultDelay
+)
+
+func init() {
+	lifecycle.MakeActionPlugin("mongo.exec", actionMongoExecParse)
+}
+
+func actionMongoExecParse(buf []byte) (lifecycle.Action, error) {
+	action := &actionMongoExec{
+		ActionConfig: lifecycle.ActionConfig{
+			Retries: defaultRetries,
+			Timeout: defaultTimeout,
+			Delay:   defaultDelay,
+		},
+		Command: bson.D{{Name: "ping", Value: 1}},
+	}
+	err := json.Unmarshal(buf, action)
+	if err != nil {
+		return nil, err
+	}
+
+	{
+		val, err := jsonparser.GetString(buf, "database")
+		switch {
+		case err == nil:
+			action.Database = val
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	{
+		buf, dt, _, err := jsonparser.Get(buf, "command")
+		switch {
+		case err == nil:
+			cmd, err := parseCommand(buf, dt)
+			if err != nil {
+				return nil, fmt.Errorf("mongo.exec: command: %v", err)
+			}
+			action.Command = cmd
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	return action, nil
+}
+
+type actionMongoExec struct {
+	lifecycle.ActionConfig
+
+	// database to run the command against.  the params
+	// database is used if empty.
+	Database string
+
+	Command bson.D
+}
+
+func (a *actionMongoExec) Do(e lifecycle.Env, p params.Params) error {
+	session, err := dial(e, p, a.Timeout)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	dbname := a.Database
+	if dbname == "" {
+		dbname = p.Database
+	}
+
+	e.Log().WithField("database", dbname).
+		WithField("command", a.Command[0].Name).
+		Debug("executing")
+
+	var result bson.M
+	err = session.DB(dbname).Run(a.Command, &result)
+	if err != nil {
+		e.Log().WithError(err).Debug("ERROR: executing")
+	}
+	return err
+}
+
+// parseCommand converts a command document to a bson.D.  The first
+// key of a document is the name of the command, so key order is
+// preserved.  The command may also be given as a list of single-key
+// documents, for configurations (eg. YAML) that don't preserve key order.
+func parseCommand(buf []byte, dt jsonparser.ValueType) (bson.D, error) {
+	var cmd bson.D
+
+	switch dt {
+	case jsonparser.Object:
+		doc, err := parseDocument(buf)
+		if err != nil {
+			return nil, err
+		}
+		cmd = doc
+	case jsonparser.Array:
+		var perr error
+		_, err := jsonparser.ArrayEach(buf, func(vbuf []byte, vt jsonparser.ValueType, _ int, err error) {
+			if perr != nil {
+				return
+			}
+			if err != nil {
+				perr = err
+				return
+			}
+			if vt != jsonparser.Object {
+				perr = fmt.Errorf("list entries must be documents")
+				return
+			}
+			doc, err := parseDocument(vbuf)
+			if err != nil {
+				perr = err
+				return
+			}
+			cmd = append(cmd, doc...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if perr != nil {
+			return nil, perr
+		}
+	default:
+		return nil, fmt.Errorf("must be a document")
+	}
+
+	if len(cmd) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	return cmd, nil
+}
+
+func parseDocument(buf []byte) (bson.D, error) {
+	doc := bson.D{}
+	err := jsonparser.ObjectEach(buf, func(key []byte, vbuf []byte, vt jsonparser.ValueType, _ int) error {
+		val, err := parseValue(vbuf, vt)
+		if err != nil {
+			return err
+		}
+		doc = append(doc, bson.DocElem{Name: string(key), Value: val})
+		return nil
+	})
+	return doc, err
+}
+
+func parseValue(buf []byte, vt jsonparser.ValueType) (interface{}, error) {
+	switch vt {
+	case jsonparser.Object:
+		return parseDocument(buf)
+	case jsonparser.Array:
+		vals := []interface{}{}
+		var perr error
+		_, err := jsonparser.ArrayEach(buf, func(vbuf []byte, vt jsonparser.ValueType, _ int, err error) {
+			if perr != nil {
+				return
+			}
+			if err != nil {
+				perr = err
+				return
+			}
+			val, err := parseValue(vbuf, vt)
+			if err != nil {
+				perr = err
+				return
+			}
+			vals = append(vals, val)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return vals, perr
+	case jsonparser.String:
+		return jsonparser.ParseString(buf)
+	case jsonparser.Number:
+		if val, err := strconv.ParseInt(string(buf), 10, 64); err == nil {
+			if int64(int32(val)) == val {
+				return int(val), nil
+			}
+			return val, nil
+		}
+		return jsonparser.ParseFloat(buf)
+	case jsonparser.Boolean:
+		return jsonparser.ParseBoolean(buf)
+	case jsonparser.Null:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("invalid value: %s", buf)
+	}
+}
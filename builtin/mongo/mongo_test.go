@@ -0,0 +1,60 @@
+package mongo_test
+
+import (
+	"testing"
+
+	"github.com/boz/ephemerald"
+	"github.com/boz/ephemerald/params"
+	"github.com/boz/ephemerald/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestActionsPingExec(t *testing.T) {
+	files := []string{"pool.json", "pool.yaml"}
+
+	for _, file := range files {
+		testutil.RunPoolFromFile(t, file, func(p params.Params) {
+			session, err := mgo.Dial(p.Url)
+			require.NoError(t, err, file)
+			defer session.Close()
+
+			names, err := session.DB(p.Database).CollectionNames()
+			require.NoError(t, err, file)
+			assert.Contains(t, names, "users", file)
+		})
+	}
+}
+
+func TestActionTruncate(t *testing.T) {
+	testutil.WithPoolFromFile(t, "pool.json", func(pool ephemerald.Pool) {
+		func() {
+			p, err := pool.Checkout()
+			require.NoError(t, err)
+			defer pool.Return(p)
+
+			session, err := mgo.Dial(p.Url)
+			require.NoError(t, err)
+			defer session.Close()
+
+			err = session.DB(p.Database).C("users").Insert(bson.M{"name": "testuser"})
+			require.NoError(t, err)
+		}()
+
+		func() {
+			p, err := pool.Checkout()
+			require.NoError(t, err)
+			defer pool.Return(p)
+
+			session, err := mgo.Dial(p.Url)
+			require.NoError(t, err)
+			defer session.Close()
+
+			count, err := session.DB(p.Database).C("users").Count()
+			require.NoError(t, err)
+			assert.Equal(t, 0, count)
+		}()
+	})
+}
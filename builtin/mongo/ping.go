@@ -0,0 +1,41 @@
+package mongo
+
+import (
+	"encoding/json"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+)
+
+func init() {
+	lifecycle.MakeActionPlugin("mongo.ping", actionMongoPingParse)
+}
+
+func actionMongoPingParse(buf []byte) (lifecycle.Action, error) {
+	action := &actionMongoPing{
+		ActionConfig: lifecycle.ActionConfig{
+			Retries: defaultRetries,
+			Timeout: defaultTimeout,
+			Delay:   defaultDelay,
+		},
+	}
+	return action, json.Unmarshal(buf, action)
+}
+
+type actionMongoPing struct {
+	lifecycle.ActionConfig
+}
+
+func (a *actionMongoPing) Do(e lifecycle.Env, p params.Params) error {
+	session, err := dial(e, p, a.Timeout)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	err = session.Ping()
+	if err != nil {
+		e.Log().WithError(err).Debug("ERROR: ping")
+	}
+	return err
+}
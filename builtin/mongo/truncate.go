@@ -0,0 +1,172 @@
+package mongo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+	mgo "gopkg.in/mgo.v2"
+)
+
+const (
+	// drop every database other than the system databases.
+	truncateDatabases = "databases"
+
+	// drop every collection in the params database.
+	truncateCollections = "collections"
+)
+
+// databases and collections that are never dropped.
+var (
+	systemDatabases = []string{"admin", "config", "local"}
+
+	systemCollectionPrefix = "system."
+)
+
+func init() {
+	lifecycle.MakeActionPlugin("mongo.truncate", actionMongoTruncateParse)
+}
+
+func actionMongoTruncateParse(buf []byte) (lifecycle.Action, error) {
+	action := &actionMongoTruncate{
+		ActionConfig: lifecycle.ActionConfig{
+			Retries: defaultRetries,
+			Timeout: defaultTimeout,
+			Delay:   defaultDelay,
+		},
+		Drop: truncateDatabases,
+	}
+	err := json.Unmarshal(buf, action)
+
+	if err != nil {
+		return nil, err
+	}
+
+	{
+		val, err := jsonparser.GetString(buf, "drop")
+		switch {
+		case err == nil:
+			action.Drop = val
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	switch action.Drop {
+	case truncateDatabases, truncateCollections:
+	default:
+		return nil, fmt.Errorf("mongo.truncate: drop: must be '%v' or '%v'", truncateDatabases, truncateCollections)
+	}
+
+	{
+		buf, dt, _, err := jsonparser.Get(buf, "exclude")
+		switch {
+		case err == nil:
+			switch dt {
+			case jsonparser.Array:
+				err = json.Unmarshal(buf, &action.Exclude)
+				if err != nil {
+					return nil, err
+				}
+			case jsonparser.String:
+				action.Exclude = []string{string(buf)}
+			default:
+				return nil, fmt.Errorf("mongo.truncate: exclude: bad type")
+			}
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	return action, nil
+}
+
+type actionMongoTruncate struct {
+	lifecycle.ActionConfig
+
+	// "databases" or "collections"
+	Drop string
+
+	// names of databases or collections to keep.
+	Exclude []string
+}
+
+func (a *actionMongoTruncate) Do(e lifecycle.Env, p params.Params) error {
+	session, err := dial(e, p, a.Timeout)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	if a.Drop == truncateCollections {
+		return a.dropCollections(e, session, p.Database)
+	}
+	return a.dropDatabases(e, session)
+}
+
+func (a *actionMongoTruncate) dropDatabases(e lifecycle.Env, session *mgo.Session) error {
+	names, err := session.DatabaseNames()
+	if err != nil {
+		e.Log().WithError(err).Debug("ERROR: list databases")
+		return err
+	}
+
+	for _, name := range names {
+		if contains(systemDatabases, name) || a.excluded(name) {
+			continue
+		}
+		if err := session.DB(name).DropDatabase(); err != nil {
+			e.Log().WithError(err).WithField("database", name).Debug("ERROR: drop database")
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *actionMongoTruncate) dropCollections(e lifecycle.Env, session *mgo.Session, dbname string) error {
+	if dbname == "" {
+		return fmt.Errorf("mongo.truncate: no database given")
+	}
+
+	db := session.DB(dbname)
+
+	names, err := db.CollectionNames()
+	if err != nil {
+		e.Log().WithError(err).Debug("ERROR: list collections")
+		return err
+	}
+
+	for _, name := range names {
+		if strings.HasPrefix(name, systemCollectionPrefix) || a.excluded(name) {
+			continue
+		}
+		if err := db.C(name).DropCollection(); err != nil {
+			e.Log().WithError(err).WithField("collection", name).Debug("ERROR: drop collection")
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *actionMongoTruncate) excluded(name string) bool {
+	for _, skipName := range a.Exclude {
+		if strings.ToLower(skipName) == strings.ToLower(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(names []string, name string) bool {
+	for _, val := range names {
+		if val == name {
+			return true
+		}
+	}
+	return false
+}
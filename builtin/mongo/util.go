@@ -0,0 +1,42 @@
+package mongo
+
+import (
+	"net"
+	"time"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	mgo "gopkg.in/mgo.v2"
+)
+
+func dial(e lifecycle.Env, p params.Params, timeout time.Duration) (*mgo.Session, error) {
+	address := net.JoinHostPort(p.Hostname, p.Port)
+
+	e.Log().WithField("address", address).Debug("dialing")
+
+	session, err := mgo.DialWithInfo(&mgo.DialInfo{
+		Addrs:    []string{address},
+		Direct:   true,
+		FailFast: true,
+		Timeout:  timeout,
+		Database: p.Database,
+		Username: p.Username,
+		Password: p.Password,
+	})
+	if err != nil {
+		e.Log().WithError(err).Debug("ERROR: dialing")
+		return nil, err
+	}
+
+	session.SetSocketTimeout(timeout)
+	session.SetMode(mgo.Monotonic, true)
+
+	// close the session to abort pending operations when the
+	// attempt is cancelled.
+	go func() {
+		<-e.Context().Done()
+		session.Close()
+	}()
+
+	return session, nil
+}
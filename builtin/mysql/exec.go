@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+)
+
+const (
+	defaultRetries = 10
+	defaultTimeout = lifecycle.ActionDefaultTimeout
+	defaultDelay   = lifecycle.ActionDefaultDelay
+)
+
+func init() {
+	lifecycle.MakeActionPlugin("mysql.exec", actionMySQLExecParse)
+}
+
+func actionMySQLExecParse(buf []byte) (lifecycle.Action, error) {
+	action := &actionMySQLExec{
+		ActionConfig: lifecycle.ActionConfig{
+			Retries: defaultRetries,
+			Timeout: defaultTimeout,
+			Delay:   defaultDelay,
+		},
+		Query: "SELECT 1 = 1",
+	}
+	err := json.Unmarshal(buf, action)
+	if err != nil {
+		return nil, err
+	}
+
+	{
+		val, err := jsonparser.GetString(buf, "query")
+		switch {
+		case err == nil:
+			action.Query = val
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	{
+		buf, dt, _, err := jsonparser.Get(buf, "args")
+		switch {
+		case err == nil:
+			switch dt {
+			case jsonparser.Array:
+				if err := json.Unmarshal(buf, &action.Args); err != nil {
+					return nil, err
+				}
+			case jsonparser.String, jsonparser.Number:
+				action.Args = []string{string(buf)}
+			default:
+				return nil, fmt.Errorf("mysql.exec: bad args type")
+			}
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	return action, nil
+}
+
+type actionMySQLExec struct {
+	lifecycle.ActionConfig
+	Query string
+	Args  []string
+}
+
+func (a *actionMySQLExec) Do(e lifecycle.Env, p params.Params) error {
+	db, err := OpenDB(e, p)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	args := make([]interface{}, 0, len(a.Args))
+	for _, arg := range a.Args {
+		args = append(args, arg)
+	}
+
+	e.Log().WithField("query", a.Query).Debug("executing")
+
+	_, err = db.ExecContext(e.Context(), a.Query, args...)
+	if err != nil {
+		e.Log().WithError(err).Debug("ERROR: executing")
+	}
+
+	return err
+}
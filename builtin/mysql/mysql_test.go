@@ -0,0 +1,78 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/boz/ephemerald"
+	"github.com/boz/ephemerald/params"
+	"github.com/boz/ephemerald/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionsPingExec(t *testing.T) {
+	files := []string{"pool.json", "pool.yaml"}
+
+	for _, file := range files {
+		testutil.RunPoolFromFile(t, file, func(p params.Params) {
+			db, err := sql.Open("mysql", p.Url)
+			require.NoError(t, err, file)
+			defer db.Close()
+
+			rows, err := db.Query("SELECT COUNT(*) FROM users")
+			require.NoError(t, err, file)
+			defer rows.Close()
+
+			require.True(t, rows.Next(), file)
+
+			var count int
+			require.NoError(t, rows.Scan(&count), file)
+			require.Equal(t, 0, count, file)
+		})
+	}
+}
+
+func TestActionTruncate(t *testing.T) {
+	testutil.WithPoolFromFile(t, "pool.json", func(pool ephemerald.Pool) {
+		username := "testuser"
+
+		func() {
+			p, err := pool.Checkout()
+			require.NoError(t, err)
+			defer pool.Return(p)
+
+			db, err := sql.Open("mysql", p.Url)
+			require.NoError(t, err)
+			defer db.Close()
+
+			result, err := db.Exec("INSERT INTO users (name) VALUES (?)", username)
+			require.NoError(t, err)
+
+			id, err := result.LastInsertId()
+			require.NoError(t, err)
+
+			_, err = db.Exec("INSERT INTO posts (user_id) VALUES (?)", id)
+			require.NoError(t, err)
+		}()
+
+		func() {
+			p, err := pool.Checkout()
+			require.NoError(t, err)
+			defer pool.Return(p)
+
+			db, err := sql.Open("mysql", p.Url)
+			require.NoError(t, err)
+			defer db.Close()
+
+			rows, err := db.Query("SELECT COUNT(*) FROM users WHERE name = ?", username)
+			require.NoError(t, err)
+			defer rows.Close()
+
+			require.True(t, rows.Next())
+
+			var count int
+			require.NoError(t, rows.Scan(&count))
+			require.Equal(t, 0, count)
+		}()
+	})
+}
@@ -0,0 +1,40 @@
+package mysql
+
+import (
+	"encoding/json"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+)
+
+func init() {
+	lifecycle.MakeActionPlugin("mysql.ping", actionMySQLPingParse)
+}
+
+func actionMySQLPingParse(buf []byte) (lifecycle.Action, error) {
+	action := &actionMySQLPing{
+		ActionConfig: lifecycle.ActionConfig{
+			Retries: defaultRetries,
+			Timeout: defaultTimeout,
+			Delay:   defaultDelay,
+		},
+	}
+	return action, json.Unmarshal(buf, action)
+}
+
+type actionMySQLPing struct {
+	lifecycle.ActionConfig
+}
+
+func (a *actionMySQLPing) Do(e lifecycle.Env, p params.Params) error {
+	db, err := OpenDB(e, p)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	err = db.PingContext(e.Context())
+	if err != nil {
+		e.Log().WithError(err).Debug("ERROR: ping")
+	}
+	return err
+}
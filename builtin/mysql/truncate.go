@@ -0,0 +1,131 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+)
+
+func init() {
+	lifecycle.MakeActionPlugin("mysql.truncate", actionMySQLTruncateParse)
+}
+
+func actionMySQLTruncateParse(buf []byte) (lifecycle.Action, error) {
+	action := &actionMySQLTruncate{
+		ActionConfig: lifecycle.ActionConfig{
+			Retries: defaultRetries,
+			Timeout: defaultTimeout,
+			Delay:   defaultDelay,
+		},
+	}
+	err := json.Unmarshal(buf, action)
+
+	if err != nil {
+		return nil, err
+	}
+
+	{
+		buf, dt, _, err := jsonparser.Get(buf, "exclude")
+		switch {
+		case err == nil:
+			switch dt {
+			case jsonparser.Array:
+				err = json.Unmarshal(buf, &action.Exclude)
+				if err != nil {
+					return nil, err
+				}
+			case jsonparser.String:
+				action.Exclude = []string{string(buf)}
+			default:
+				return nil, fmt.Errorf("mysql.truncate: exclude: bad type")
+			}
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	return action, nil
+}
+
+type actionMySQLTruncate struct {
+	lifecycle.ActionConfig
+	Exclude []string
+}
+
+// Do truncates every table in the database with foreign key
+// checks disabled so that tables can be truncated in any order.
+func (a *actionMySQLTruncate) Do(e lifecycle.Env, p params.Params) error {
+	db, err := OpenDB(e, p)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// FOREIGN_KEY_CHECKS is set per-session; use a single connection.
+	db.SetMaxOpenConns(1)
+
+	ctx := e.Context()
+
+	tables, err := a.tables(e, db)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		e.Log().WithError(err).Debug("ERROR: disable foreign key checks")
+		return err
+	}
+
+	for _, name := range tables {
+		if _, err := db.ExecContext(ctx, "TRUNCATE TABLE "+QuoteIdentifier(name)); err != nil {
+			e.Log().WithError(err).Debug("ERROR: truncate")
+			db.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+			return err
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1"); err != nil {
+		e.Log().WithError(err).Debug("ERROR: enable foreign key checks")
+		return err
+	}
+
+	return nil
+}
+
+// tables returns the tables in the current database that are not excluded.
+func (a *actionMySQLTruncate) tables(e lifecycle.Env, db *sql.DB) ([]string, error) {
+	rows, err := db.QueryContext(e.Context(),
+		"SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'")
+	if err != nil {
+		e.Log().WithError(err).Debug("ERROR: select tables")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+
+rows:
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			e.Log().WithError(err).Debug("ERROR: scan")
+			return nil, err
+		}
+
+		for _, skipName := range a.Exclude {
+			if strings.ToLower(skipName) == strings.ToLower(name) {
+				continue rows
+			}
+		}
+
+		tables = append(tables, name)
+	}
+
+	return tables, rows.Err()
+}
@@ -0,0 +1,39 @@
+package mysql
+
+import (
+	"database/sql"
+	"net"
+	"strings"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/go-sql-driver/mysql"
+)
+
+// OpenDB connects to the database described by the params.
+func OpenDB(e lifecycle.Env, p params.Params) (*sql.DB, error) {
+	dsn := mysqlDSN(p)
+	e.Log().WithField("address", net.JoinHostPort(p.Hostname, p.Port)).Debug("open")
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		e.Log().WithError(err).Error("open")
+	}
+	return db, err
+}
+
+func mysqlDSN(p params.Params) string {
+	cfg := mysql.Config{
+		User:            p.Username,
+		Passwd:          p.Password,
+		Net:             "tcp",
+		Addr:            net.JoinHostPort(p.Hostname, p.Port),
+		DBName:          p.Database,
+		MultiStatements: true,
+	}
+	return cfg.FormatDSN()
+}
+
+// QuoteIdentifier quotes a table or column name.
+func QuoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
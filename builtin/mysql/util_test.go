@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/boz/ephemerald/params"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMysqlDSN(t *testing.T) {
+	p := params.Params{
+		Hostname: "localhost",
+		Port:     "3306",
+		Config: params.Config{
+			Username: "root",
+			Password: "p@ss:/word",
+			Database: "test",
+		},
+	}
+
+	cfg, err := mysql.ParseDSN(mysqlDSN(p))
+	require.NoError(t, err)
+
+	assert.Equal(t, "root", cfg.User)
+	assert.Equal(t, "p@ss:/word", cfg.Passwd)
+	assert.Equal(t, "tcp", cfg.Net)
+	assert.Equal(t, "localhost:3306", cfg.Addr)
+	assert.Equal(t, "test", cfg.DBName)
+	assert.True(t, cfg.MultiStatements)
+}
@@ -0,0 +1,11 @@
+create table users (
+  id serial primary key,
+  name varchar(255),
+  email varchar(255),
+  unique(name)
+);
+create table posts (
+  id serial primary key,
+  user_id integer references users (id),
+  title varchar(255)
+);
@@ -71,7 +71,7 @@ type actionPGExec struct {
 }
 
 func (a *actionPGExec) Do(e lifecycle.Env, p params.Params) error {
-	db, err := openDB(e, p)
+	db, err := OpenDB(e, p)
 	if err != nil {
 		return err
 	}
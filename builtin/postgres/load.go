@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+	"github.com/lib/pq"
+)
+
+const (
+	loadDefaultTimeout = 10 * time.Second
+)
+
+func init() {
+	lifecycle.MakeActionPlugin("postgres.load", actionPGLoadParse)
+}
+
+func actionPGLoadParse(buf []byte) (lifecycle.Action, error) {
+	action := &actionPGLoad{
+		ActionConfig: lifecycle.ActionConfig{
+			Retries: defaultRetries,
+			Timeout: loadDefaultTimeout,
+			Delay:   defaultDelay,
+		},
+	}
+	err := json.Unmarshal(buf, action)
+	if err != nil {
+		return nil, err
+	}
+
+	if action.Files, err = parseStrings(buf, "file"); err != nil {
+		return nil, err
+	}
+
+	{
+		val, _, _, err := jsonparser.Get(buf, "copy")
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(val, &action.Copy); err != nil {
+				return nil, fmt.Errorf("postgres.load: copy: %v", err)
+			}
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	if len(action.Files) == 0 && len(action.Copy) == 0 {
+		return nil, fmt.Errorf("postgres.load: file or copy required")
+	}
+
+	for _, c := range action.Copy {
+		if c.Table == "" || c.File == "" {
+			return nil, fmt.Errorf("postgres.load: copy: table and file required")
+		}
+	}
+
+	return action, nil
+}
+
+// actionPGLoad seeds the database with fixtures.  SQL files are executed
+// first, then CSV files are copied into their tables, all in a single
+// transaction.
+type actionPGLoad struct {
+	lifecycle.ActionConfig
+
+	// SQL files to execute, in order.
+	Files []string
+
+	// CSV files to copy into tables, in order.
+	Copy []pgCopy
+}
+
+// pgCopy loads a CSV file into a table.  The first row of the
+// file names the columns.
+type pgCopy struct {
+	// table name, optionally qualified by schema ("schema.table").
+	Table string `json:"table"`
+	File  string `json:"file"`
+}
+
+func (a *actionPGLoad) Do(e lifecycle.Env, p params.Params) error {
+	db, err := OpenDB(e, p)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTx(e.Context(), nil)
+	if err != nil {
+		e.Log().WithError(err).Debug("ERROR: begin")
+		return err
+	}
+
+	if err := a.load(e, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		e.Log().WithError(err).Debug("ERROR: commit")
+		return err
+	}
+	return nil
+}
+
+func (a *actionPGLoad) load(e lifecycle.Env, tx *sql.Tx) error {
+	for _, file := range a.Files {
+		e.Step(file)
+
+		body, err := ioutil.ReadFile(e.Path(file))
+		if err != nil {
+			e.Log().WithError(err).Error("reading sql")
+			return err
+		}
+
+		if _, err := tx.ExecContext(e.Context(), string(body)); err != nil {
+			e.Log().WithError(err).WithField("file", file).Debug("ERROR: exec")
+			return fmt.Errorf("%v: %v", file, err)
+		}
+	}
+
+	for _, c := range a.Copy {
+		e.Step(c.File)
+
+		if err := c.load(e, tx); err != nil {
+			e.Log().WithError(err).WithField("file", c.File).Debug("ERROR: copy")
+			return fmt.Errorf("%v: %v", c.File, err)
+		}
+	}
+
+	return nil
+}
+
+func (c pgCopy) load(e lifecycle.Env, tx *sql.Tx) error {
+	file, err := os.Open(e.Path(c.File))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+
+	columns, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %v", err)
+	}
+
+	stmt, err := tx.PrepareContext(e.Context(), c.statement(columns))
+	if err != nil {
+		return err
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			stmt.Close()
+			return err
+		}
+		if _, err := stmt.ExecContext(e.Context(), copyValues(row)...); err != nil {
+			stmt.Close()
+			return err
+		}
+	}
+
+	if _, err := stmt.ExecContext(e.Context()); err != nil {
+		stmt.Close()
+		return err
+	}
+
+	return stmt.Close()
+}
+
+func (c pgCopy) statement(columns []string) string {
+	if parts := strings.SplitN(c.Table, ".", 2); len(parts) == 2 {
+		return pq.CopyInSchema(parts[0], parts[1], columns...)
+	}
+	return pq.CopyIn(c.Table, columns...)
+}
+
+// copyValues converts a CSV row to COPY values.  As with
+// COPY ... CSV, empty fields are NULL.
+func copyValues(row []string) []interface{} {
+	values := make([]interface{}, len(row))
+	for i, field := range row {
+		if field != "" {
+			values[i] = field
+		}
+	}
+	return values
+}
@@ -27,7 +27,7 @@ type actionPGPing struct {
 }
 
 func (a *actionPGPing) Do(e lifecycle.Env, p params.Params) error {
-	db, err := openDB(e, p)
+	db, err := OpenDB(e, p)
 	if err != nil {
 		return err
 	}
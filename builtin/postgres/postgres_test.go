@@ -70,3 +70,109 @@ func TestActionTruncate(t *testing.T) {
 		}()
 	})
 }
+
+func TestActionTemplate(t *testing.T) {
+	for _, file := range []string{"pool.template.json", "pool.template.yaml"} {
+		testutil.WithPoolFromFile(t, file, func(pool ephemerald.Pool) {
+			func() {
+				p, err := pool.Checkout()
+				require.NoError(t, err, file)
+				defer pool.Return(p)
+
+				db, err := sql.Open("postgres", p.Url)
+				require.NoError(t, err, file)
+				defer db.Close()
+
+				_, err = db.Exec("INSERT INTO users (name) VALUES ($1)", "testuser")
+				require.NoError(t, err, file)
+			}()
+
+			func() {
+				p, err := pool.Checkout()
+				require.NoError(t, err, file)
+				defer pool.Return(p)
+
+				db, err := sql.Open("postgres", p.Url)
+				require.NoError(t, err, file)
+				defer db.Close()
+
+				var names []string
+				rows, err := db.Query("SELECT name FROM users")
+				require.NoError(t, err, file)
+				defer rows.Close()
+
+				for rows.Next() {
+					var name string
+					require.NoError(t, rows.Scan(&name), file)
+					names = append(names, name)
+				}
+				require.Equal(t, []string{"seed"}, names, file)
+			}()
+		})
+	}
+}
+
+func TestActionLoad(t *testing.T) {
+	for _, file := range []string{"pool.load.json", "pool.load.yaml"} {
+		testutil.WithPoolFromFile(t, file, func(pool ephemerald.Pool) {
+			for i := 0; i < 2; i++ {
+				func() {
+					p, err := pool.Checkout()
+					require.NoError(t, err, file)
+					defer pool.Return(p)
+
+					db, err := sql.Open("postgres", p.Url)
+					require.NoError(t, err, file)
+					defer db.Close()
+
+					var email sql.NullString
+					require.NoError(t, db.QueryRow("SELECT email FROM users WHERE name = 'bob'").Scan(&email), file)
+					require.False(t, email.Valid, file)
+
+					var count int
+					require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM posts WHERE user_id = 1").Scan(&count), file)
+					require.Equal(t, 2, count, file)
+
+					_, err = db.Exec("INSERT INTO posts (user_id, title) VALUES (1, 'extra')")
+					require.NoError(t, err, file)
+				}()
+			}
+		})
+	}
+}
+
+func TestSharedPool(t *testing.T) {
+	for _, file := range []string{"pool.shared.json", "pool.shared.yaml"} {
+		testutil.WithPoolFromFile(t, file, func(pool ephemerald.Pool) {
+			a, err := pool.Checkout()
+			require.NoError(t, err, file)
+			defer pool.Return(a)
+
+			b, err := pool.Checkout()
+			require.NoError(t, err, file)
+			defer pool.Return(b)
+
+			require.NotEqual(t, a.Database, b.Database, file)
+			require.NotEqual(t, a.Username, b.Username, file)
+			require.Equal(t, a.Port, b.Port, file)
+
+			adb, err := sql.Open("postgres", a.Url)
+			require.NoError(t, err, file)
+			defer adb.Close()
+
+			bdb, err := sql.Open("postgres", b.Url)
+			require.NoError(t, err, file)
+			defer bdb.Close()
+
+			_, err = adb.Exec("INSERT INTO users (name) VALUES ($1)", "testuser")
+			require.NoError(t, err, file)
+
+			var count int
+			require.NoError(t, adb.QueryRow("SELECT COUNT(*) FROM users").Scan(&count), file)
+			require.Equal(t, 2, count, file)
+
+			require.NoError(t, bdb.QueryRow("SELECT COUNT(*) FROM users").Scan(&count), file)
+			require.Equal(t, 1, count, file)
+		})
+	}
+}
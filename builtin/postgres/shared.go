@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/lib/pq"
+)
+
+// time allowed to drop what was created when creating a database fails.
+const sharedPGCleanupTimeout = 10 * time.Second
+
+func init() {
+	lifecycle.MakeDatabaseProviderPlugin("postgres", sharedPGParse)
+}
+
+func sharedPGParse(buf []byte) (lifecycle.DatabaseProvider, error) {
+	provider := &sharedPG{}
+	if err := json.Unmarshal(buf, provider); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// sharedPG creates a database, and optionally a role that owns
+// it, for each checkout of a shared postgres pool.
+type sharedPG struct {
+	// create a login role with a random password for each database.
+	Role bool `json:"role"`
+
+	// database to copy new databases from.
+	Template string `json:"template"`
+}
+
+func (s *sharedPG) Create(e lifecycle.Env, server params.Params, name string) (params.Params, error) {
+	db, err := OpenDB(e, s.maintenanceParams(server))
+	if err != nil {
+		return params.Params{}, err
+	}
+	defer db.Close()
+
+	ctx := e.Context()
+	result := server
+	result.Database = name
+
+	// drop the role, and the database once it exists, if a later
+	// step fails.  setup may have failed because the action timed
+	// out, so cleanup gets its own deadline.
+	created := false
+	fail := func(err error) (params.Params, error) {
+		cctx, cancel := context.WithTimeout(context.Background(), sharedPGCleanupTimeout)
+		defer cancel()
+
+		var derr error
+		switch {
+		case created:
+			derr = s.Drop(lifecycle.NewEnv(cctx, e.Log()), server, result)
+		case s.Role:
+			_, derr = db.ExecContext(cctx, "DROP ROLE IF EXISTS "+pq.QuoteIdentifier(name))
+		}
+		if derr != nil {
+			e.Log().WithError(derr).Warn("unable to clean up after failed create")
+		}
+		return params.Params{}, err
+	}
+
+	query := "CREATE DATABASE " + pq.QuoteIdentifier(name)
+
+	if s.Role {
+		password, err := randomPassword()
+		if err != nil {
+			return params.Params{}, err
+		}
+
+		_, err = db.ExecContext(ctx, fmt.Sprintf("CREATE ROLE %v LOGIN PASSWORD %v",
+			pq.QuoteIdentifier(name), quoteLiteral(password)))
+		if err != nil {
+			e.Log().WithError(err).Debug("ERROR: create role")
+			return params.Params{}, err
+		}
+
+		result.Username = name
+		result.Password = password
+		query += " OWNER " + pq.QuoteIdentifier(name)
+	}
+
+	if s.Template != "" {
+		// CREATE DATABASE fails if there are other connections to the template.
+		if err := terminateConnections(ctx, db, s.Template); err != nil {
+			e.Log().WithError(err).Debug("ERROR: terminate connections")
+			return fail(err)
+		}
+		query += " TEMPLATE " + pq.QuoteIdentifier(s.Template)
+	}
+
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		e.Log().WithError(err).Debug("ERROR: create database")
+		return fail(err)
+	}
+	created = true
+
+	if s.Role && s.Template != "" {
+		// objects copied from the template are owned by the server's user.
+		if err := grantAll(e, server, name); err != nil {
+			e.Log().WithError(err).Debug("ERROR: grant")
+			return fail(err)
+		}
+	}
+
+	p, err := result.ForHost(result.Hostname)
+	if err != nil {
+		return fail(err)
+	}
+	return p, nil
+}
+
+func (s *sharedPG) Drop(e lifecycle.Env, server params.Params, p params.Params) error {
+	db, err := OpenDB(e, s.maintenanceParams(server))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := e.Context()
+
+	if err := terminateConnections(ctx, db, p.Database); err != nil {
+		e.Log().WithError(err).Debug("ERROR: terminate connections")
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, "DROP DATABASE IF EXISTS "+pq.QuoteIdentifier(p.Database)); err != nil {
+		e.Log().WithError(err).Debug("ERROR: drop database")
+		return err
+	}
+
+	if s.Role && p.Username != server.Username {
+		if _, err := db.ExecContext(ctx, "DROP ROLE IF EXISTS "+pq.QuoteIdentifier(p.Username)); err != nil {
+			e.Log().WithError(err).Debug("ERROR: drop role")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// grantAll gives role every privilege on the objects in
+// the database of the same name.
+func grantAll(e lifecycle.Env, server params.Params, role string) error {
+	server.Database = role
+
+	db, err := OpenDB(e, server)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := e.Context()
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT nspname FROM pg_catalog.pg_namespace
+		 WHERE nspname NOT IN ('pg_catalog', 'information_schema')
+		 AND nspname NOT LIKE 'pg\_%'`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var schemas []string
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return err
+		}
+		schemas = append(schemas, schema)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, schema := range schemas {
+		for _, query := range []string{
+			"GRANT ALL ON SCHEMA %v TO %v",
+			"GRANT ALL ON ALL TABLES IN SCHEMA %v TO %v",
+			"GRANT ALL ON ALL SEQUENCES IN SCHEMA %v TO %v",
+			"GRANT ALL ON ALL FUNCTIONS IN SCHEMA %v TO %v",
+		} {
+			query = fmt.Sprintf(query, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(role))
+			if _, err := db.ExecContext(ctx, query); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// maintenanceParams returns params for connecting to the server
+// with a database that isn't the template.
+func (s *sharedPG) maintenanceParams(server params.Params) params.Params {
+	if server.Database == "" || server.Database == s.Template {
+		server.Database = maintenanceDatabase(server.Database, s.Template)
+	}
+	return server
+}
+
+func randomPassword() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func quoteLiteral(val string) string {
+	return "'" + strings.Replace(val, "'", "''", -1) + "'"
+}
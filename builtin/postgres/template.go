@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+	"github.com/lib/pq"
+)
+
+const (
+	templateDefaultTimeout = 10 * time.Second
+	templateSuffix         = "_template"
+)
+
+func init() {
+	lifecycle.MakeActionPlugin("postgres.template", actionPGTemplateParse)
+}
+
+func actionPGTemplateParse(buf []byte) (lifecycle.Action, error) {
+	action := &actionPGTemplate{
+		ActionConfig: lifecycle.ActionConfig{
+			Retries: defaultRetries,
+			Timeout: templateDefaultTimeout,
+			Delay:   defaultDelay,
+		},
+	}
+	err := json.Unmarshal(buf, action)
+	if err != nil {
+		return nil, err
+	}
+
+	{
+		val, err := jsonparser.GetBoolean(buf, "snapshot")
+		switch {
+		case err == nil:
+			action.Snapshot = val
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	{
+		val, err := jsonparser.GetString(buf, "template")
+		switch {
+		case err == nil:
+			action.Template = val
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	return action, nil
+}
+
+// actionPGTemplate resets the database by recreating it from a
+// template.  With Snapshot set, it instead copies the database
+// to the template; this should be run at the end of initialize.
+type actionPGTemplate struct {
+	lifecycle.ActionConfig
+
+	// copy the database to the template instead of restoring it.
+	Snapshot bool
+
+	// name of the template database.  defaults to the
+	// params database with a "_template" suffix.
+	Template string
+}
+
+func (a *actionPGTemplate) Do(e lifecycle.Env, p params.Params) error {
+	if p.Database == "" {
+		return fmt.Errorf("postgres.template: no database given")
+	}
+
+	template := a.Template
+	if template == "" {
+		template = p.Database + templateSuffix
+	}
+
+	if template == p.Database {
+		return fmt.Errorf("postgres.template: template must differ from database")
+	}
+
+	// a database can't be dropped or copied from a connection to itself.
+	mp := p
+	mp.Database = maintenanceDatabase(p.Database, template)
+
+	db, err := OpenDB(e, mp)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if a.Snapshot {
+		e.Log().WithField("template", template).Debug("snapshot")
+		return copyDatabase(e, db, p.Database, template)
+	}
+
+	e.Log().WithField("template", template).Debug("restore")
+	return copyDatabase(e, db, template, p.Database)
+}
+
+// maintenanceDatabase returns a database to connect to that is
+// neither of the given databases.
+func maintenanceDatabase(database, template string) string {
+	for _, candidate := range []string{"postgres", "template1"} {
+		if candidate != database && candidate != template {
+			return candidate
+		}
+	}
+	return "template1"
+}
+
+// copyDatabase replaces dst with a copy of src.
+func copyDatabase(e lifecycle.Env, db *sql.DB, src string, dst string) error {
+	ctx := e.Context()
+
+	// CREATE DATABASE fails if there are other connections to the source.
+	for _, name := range []string{src, dst} {
+		if err := terminateConnections(ctx, db, name); err != nil {
+			e.Log().WithError(err).WithField("database", name).Debug("ERROR: terminate connections")
+			return err
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, "DROP DATABASE IF EXISTS "+pq.QuoteIdentifier(dst)); err != nil {
+		e.Log().WithError(err).Debug("ERROR: drop database")
+		return err
+	}
+
+	query := fmt.Sprintf("CREATE DATABASE %v TEMPLATE %v", pq.QuoteIdentifier(dst), pq.QuoteIdentifier(src))
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		e.Log().WithError(err).Debug("ERROR: create database")
+		return err
+	}
+
+	return nil
+}
+
+func terminateConnections(ctx context.Context, db *sql.DB, name string) error {
+	_, err := db.ExecContext(ctx,
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1 AND pid <> pg_backend_pid()",
+		name)
+	return err
+}
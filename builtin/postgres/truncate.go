@@ -3,11 +3,19 @@ package postgres
 import (
 	"encoding/json"
 	"fmt"
+	"path"
+	"regexp"
 	"strings"
 
 	"github.com/boz/ephemerald/lifecycle"
 	"github.com/boz/ephemerald/params"
 	"github.com/buger/jsonparser"
+	"github.com/lib/pq"
+)
+
+const (
+	// schema name that selects every non-system schema.
+	allSchemas = "*"
 )
 
 func init() {
@@ -21,6 +29,8 @@ func actionPGTruncateParse(buf []byte) (lifecycle.Action, error) {
 			Timeout: defaultTimeout,
 			Delay:   defaultDelay,
 		},
+		Schemas:         []string{"public"},
+		RestartIdentity: true,
 	}
 	err := json.Unmarshal(buf, action)
 
@@ -28,78 +38,217 @@ func actionPGTruncateParse(buf []byte) (lifecycle.Action, error) {
 		return nil, err
 	}
 
+	if action.Exclude, err = parseStrings(buf, "exclude"); err != nil {
+		return nil, err
+	}
+
+	{
+		schemas, err := parseStrings(buf, "schemas")
+		if err != nil {
+			return nil, err
+		}
+		if schemas != nil {
+			action.Schemas = schemas
+		}
+	}
+
 	{
-		buf, dt, _, err := jsonparser.Get(buf, "exclude")
+		val, err := jsonparser.GetBoolean(buf, "restart_identity")
 		switch {
 		case err == nil:
-			switch dt {
-			case jsonparser.Array:
-				err = json.Unmarshal(buf, &action.Exclude)
-				if err != nil {
-					return nil, err
-				}
-			case jsonparser.String:
-				action.Exclude = []string{string(buf)}
-			default:
-				return nil, fmt.Errorf("postgres.truncate: exclude: bad type")
-			}
+			action.RestartIdentity = val
 		case err == jsonparser.KeyPathNotFoundError:
 		default:
 			return nil, err
 		}
 	}
 
+	if action.exclude, err = parseTableMatchers(action.Exclude); err != nil {
+		return nil, err
+	}
+
 	return action, nil
 }
 
+// parseStrings parses a string or an array of strings at key.
+// nil is returned if key isn't present.
+func parseStrings(buf []byte, key string) ([]string, error) {
+	buf, dt, _, err := jsonparser.Get(buf, key)
+	switch {
+	case err == nil:
+		switch dt {
+		case jsonparser.Array:
+			var vals []string
+			if err := json.Unmarshal(buf, &vals); err != nil {
+				return nil, err
+			}
+			return vals, nil
+		case jsonparser.String:
+			return []string{string(buf)}, nil
+		default:
+			return nil, fmt.Errorf("postgres: %v: bad type", key)
+		}
+	case err == jsonparser.KeyPathNotFoundError:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
 type actionPGTruncate struct {
 	lifecycle.ActionConfig
+
+	// schemas to truncate tables in.  "*" selects every non-system schema.
+	Schemas []string
+
+	// reset sequences owned by the truncated tables.
+	RestartIdentity bool
+
+	// table names, globs, or regular expressions (enclosed in "/")
+	// of tables to not truncate.
 	Exclude []string
+
+	exclude []tableMatcher
 }
 
 func (a *actionPGTruncate) Do(e lifecycle.Env, p params.Params) error {
-	db, err := openDB(e, p)
+	db, err := OpenDB(e, p)
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	tx, err := db.Begin()
-	if err != nil {
-		e.Log().WithError(err).Debug("ERROR: begin")
-		return err
-	}
-
-	rows, err := tx.QueryContext(e.Context(), "SELECT tablename FROM pg_catalog.pg_tables WHERE schemaname = 'public'")
+	rows, err := db.QueryContext(e.Context(),
+		`SELECT schemaname, tablename FROM pg_catalog.pg_tables
+		 WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+		 AND schemaname NOT LIKE 'pg\_%'`)
 
 	if err != nil {
-		tx.Rollback()
 		e.Log().WithError(err).Debug("ERROR: select tables")
 		return err
 	}
 
 	defer rows.Close()
 
-rows:
+	var tables []pgTable
+
 	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
-			tx.Rollback()
+		var table pgTable
+		if err := rows.Scan(&table.schema, &table.name); err != nil {
 			e.Log().WithError(err).Debug("ERROR: scan")
 			return err
 		}
+		if a.selected(table) {
+			tables = append(tables, table)
+		}
+	}
 
-		for _, skipName := range a.Exclude {
-			if strings.ToLower(skipName) == strings.ToLower(name) {
-				continue rows
+	if err := rows.Err(); err != nil {
+		e.Log().WithError(err).Debug("ERROR: select tables")
+		return err
+	}
+
+	if len(tables) == 0 {
+		return nil
+	}
+
+	query := truncateStatement(tables, a.RestartIdentity)
+
+	e.Log().WithField("query", query).Debug("truncating")
+
+	if _, err := db.ExecContext(e.Context(), query); err != nil {
+		e.Log().WithError(err).Debug("ERROR: truncate")
+		return err
+	}
+	return nil
+}
+
+// selected returns true if table is in one of the configured
+// schemas and is not excluded.
+func (a *actionPGTruncate) selected(table pgTable) bool {
+	found := false
+	for _, schema := range a.Schemas {
+		if schema == allSchemas || schema == table.schema {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+
+	for _, m := range a.exclude {
+		if m.match(table) {
+			return false
+		}
+	}
+	return true
+}
+
+type pgTable struct {
+	schema string
+	name   string
+}
+
+func (t pgTable) String() string {
+	return pq.QuoteIdentifier(t.schema) + "." + pq.QuoteIdentifier(t.name)
+}
+
+// truncateStatement returns a single statement truncating every table.
+func truncateStatement(tables []pgTable, restartIdentity bool) string {
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		names = append(names, table.String())
+	}
+
+	query := "TRUNCATE TABLE " + strings.Join(names, ", ")
+	if restartIdentity {
+		query += " RESTART IDENTITY"
+	}
+	return query + " CASCADE"
+}
+
+// tableMatcher matches a table by name or by "schema.name".
+//
+// Patterns enclosed in "/" are regular expressions; other patterns
+// are case-insensitive globs.
+type tableMatcher struct {
+	glob  string
+	regex *regexp.Regexp
+}
+
+func parseTableMatchers(patterns []string) ([]tableMatcher, error) {
+	var matchers []tableMatcher
+	for _, pattern := range patterns {
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			regex, err := regexp.Compile(pattern[1 : len(pattern)-1])
+			if err != nil {
+				return nil, fmt.Errorf("postgres.truncate: exclude: %v", err)
 			}
+			matchers = append(matchers, tableMatcher{regex: regex})
+			continue
 		}
 
-		if _, err := tx.ExecContext(e.Context(), "TRUNCATE TABLE "+name+" CASCADE"); err != nil {
-			tx.Rollback()
-			e.Log().WithError(err).Debug("ERROR: truncate")
-			return err
+		glob := strings.ToLower(pattern)
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("postgres.truncate: exclude: %v: %v", pattern, err)
+		}
+		matchers = append(matchers, tableMatcher{glob: glob})
+	}
+	return matchers, nil
+}
+
+func (m tableMatcher) match(table pgTable) bool {
+	for _, name := range []string{table.name, table.schema + "." + table.name} {
+		if m.regex != nil {
+			if m.regex.MatchString(name) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(m.glob, strings.ToLower(name)); ok {
+			return true
 		}
 	}
-	return tx.Commit()
+	return false
 }
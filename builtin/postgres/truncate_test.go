@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateStatement(t *testing.T) {
+	tables := []pgTable{{"public", "users"}, {"Audit", "LogEntries"}}
+
+	assert.Equal(t,
+		`TRUNCATE TABLE "public"."users", "Audit"."LogEntries" RESTART IDENTITY CASCADE`,
+		truncateStatement(tables, true))
+
+	assert.Equal(t,
+		`TRUNCATE TABLE "public"."users" CASCADE`,
+		truncateStatement(tables[:1], false))
+}
+
+func TestTruncateSelected(t *testing.T) {
+	action, err := actionPGTruncateParse([]byte(`{
+		"type": "postgres.truncate",
+		"schemas": ["public", "audit"],
+		"exclude": ["schema_migrations", "audit.keep_*", "/^ar_internal_/"]
+	}`))
+	require.NoError(t, err)
+
+	a := action.(*actionPGTruncate)
+	assert.True(t, a.RestartIdentity)
+
+	for table, selected := range map[pgTable]bool{
+		{"public", "users"}:                true,
+		{"public", "Schema_Migrations"}:    false,
+		{"audit", "keep_events"}:           false,
+		{"audit", "events"}:                true,
+		{"public", "keep_events"}:          true,
+		{"public", "ar_internal_metadata"}: false,
+		{"other", "users"}:                 false,
+	} {
+		assert.Equal(t, selected, a.selected(table), table.String())
+	}
+}
+
+func TestTruncateParse(t *testing.T) {
+	action, err := actionPGTruncateParse([]byte(`{"type": "postgres.truncate"}`))
+	require.NoError(t, err)
+
+	a := action.(*actionPGTruncate)
+	assert.Equal(t, []string{"public"}, a.Schemas)
+	assert.True(t, a.selected(pgTable{"public", "users"}))
+
+	action, err = actionPGTruncateParse([]byte(`{"type": "postgres.truncate", "schemas": "*", "restart_identity": false}`))
+	require.NoError(t, err)
+
+	a = action.(*actionPGTruncate)
+	assert.False(t, a.RestartIdentity)
+	assert.True(t, a.selected(pgTable{"other", "users"}))
+
+	_, err = actionPGTruncateParse([]byte(`{"type": "postgres.truncate", "exclude": "/(/"}`))
+	assert.Error(t, err)
+
+	_, err = actionPGTruncateParse([]byte(`{"type": "postgres.truncate", "exclude": "[a"}`))
+	assert.Error(t, err)
+}
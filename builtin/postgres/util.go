@@ -10,7 +10,8 @@ import (
 	_ "github.com/lib/pq"
 )
 
-func openDB(e lifecycle.Env, p params.Params) (*sql.DB, error) {
+// OpenDB connects to the database described by the params.
+func OpenDB(e lifecycle.Env, p params.Params) (*sql.DB, error) {
 	url := pgURL(p)
 	e.Log().WithField("url", url).Debug("open")
 	db, err := sql.Open("postgres", url)
@@ -0,0 +1,312 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+	rredis "github.com/garyburd/redigo/redis"
+	"github.com/ghodss/yaml"
+)
+
+func init() {
+	lifecycle.MakeActionPlugin("redis.load", actionRedisLoadParse)
+}
+
+// actionRedisLoad seeds redis from a file of commands
+// and/or a key/value document.
+type actionRedisLoad struct {
+	lifecycle.ActionConfig
+
+	// file of redis commands, one per line.
+	Commands string
+
+	// YAML or JSON document of keys to set.
+	Data string
+}
+
+func actionRedisLoadParse(buf []byte) (lifecycle.Action, error) {
+	action := &actionRedisLoad{
+		ActionConfig: lifecycle.DefaultActionConfig(),
+	}
+
+	err := json.Unmarshal(buf, action)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		key string
+		val *string
+	}{
+		{"commands", &action.Commands},
+		{"data", &action.Data},
+	} {
+		val, err := jsonparser.GetString(buf, field.key)
+		switch {
+		case err == nil:
+			*field.val = val
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	if action.Commands == "" && action.Data == "" {
+		return nil, fmt.Errorf("redis.load: commands or data required")
+	}
+
+	return action, nil
+}
+
+func (a *actionRedisLoad) Do(e lifecycle.Env, p params.Params) error {
+	var commands []redisCommand
+
+	if a.Commands != "" {
+		buf, err := ioutil.ReadFile(e.Path(a.Commands))
+		if err != nil {
+			e.Log().WithError(err).Error("reading commands")
+			return err
+		}
+		cmds, err := parseCommands(buf)
+		if err != nil {
+			return fmt.Errorf("%v: %v", a.Commands, err)
+		}
+		commands = append(commands, cmds...)
+	}
+
+	if a.Data != "" {
+		buf, err := ioutil.ReadFile(e.Path(a.Data))
+		if err != nil {
+			e.Log().WithError(err).Error("reading data")
+			return err
+		}
+		cmds, err := parseData(buf)
+		if err != nil {
+			return fmt.Errorf("%v: %v", a.Data, err)
+		}
+		commands = append(commands, cmds...)
+	}
+
+	address := net.JoinHostPort(p.Hostname, p.Port)
+
+	e.Log().WithField("address", address).Debug("dialing")
+
+	options := []rredis.DialOption{
+		rredis.DialConnectTimeout(a.Timeout),
+		rredis.DialReadTimeout(a.Timeout),
+		rredis.DialWriteTimeout(a.Timeout),
+	}
+	if db, err := strconv.Atoi(p.Database); err == nil {
+		options = append(options, rredis.DialDatabase(db))
+	}
+
+	conn, err := rredis.Dial("tcp", address, options...)
+	if err != nil {
+		e.Log().WithError(err).Debug("ERROR: dialing")
+		return err
+	}
+	defer conn.Close()
+
+	for _, cmd := range commands {
+		if err := conn.Send(cmd.name, cmd.args...); err != nil {
+			e.Log().WithError(err).Debug("ERROR: sending")
+			return err
+		}
+	}
+
+	if err := conn.Flush(); err != nil {
+		e.Log().WithError(err).Debug("ERROR: flushing")
+		return err
+	}
+
+	for _, cmd := range commands {
+		if _, err := conn.Receive(); err != nil {
+			e.Log().WithError(err).WithField("command", cmd.name).Debug("ERROR: executing")
+			return fmt.Errorf("%v: %v", cmd, err)
+		}
+	}
+
+	return nil
+}
+
+type redisCommand struct {
+	name string
+	args []interface{}
+}
+
+func (c redisCommand) String() string {
+	return strings.TrimSpace(fmt.Sprintln(append([]interface{}{c.name}, c.args...)...))
+}
+
+// parseCommands reads one command per line, with arguments
+// separated by whitespace.  Arguments may be enclosed in double quotes,
+// which support the escapes \", \\, \n, \r and \t.  Blank lines and
+// lines starting with "#" are ignored.
+func parseCommands(buf []byte) ([]redisCommand, error) {
+	var commands []redisCommand
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		words, err := splitCommand(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %v", lineno, err)
+		}
+
+		args := make([]interface{}, 0, len(words)-1)
+		for _, word := range words[1:] {
+			args = append(args, word)
+		}
+		commands = append(commands, redisCommand{words[0], args})
+	}
+
+	return commands, scanner.Err()
+}
+
+func splitCommand(line string) ([]string, error) {
+	var (
+		words   []string
+		word    []byte
+		inWord  bool
+		inQuote bool
+	)
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch {
+		case inQuote && c == '\\':
+			i++
+			if i == len(line) {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			switch line[i] {
+			case 'n':
+				word = append(word, '\n')
+			case 'r':
+				word = append(word, '\r')
+			case 't':
+				word = append(word, '\t')
+			default:
+				word = append(word, line[i])
+			}
+		case inQuote && c == '"':
+			inQuote = false
+		case inQuote:
+			word = append(word, c)
+		case c == '"':
+			inQuote = true
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, string(word))
+				word = word[:0]
+				inWord = false
+			}
+		default:
+			word = append(word, c)
+			inWord = true
+		}
+	}
+
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inWord {
+		words = append(words, string(word))
+	}
+	return words, nil
+}
+
+// parseData converts a document of keys to the commands that create them.
+//
+// Scalars are set with SET, lists with RPUSH and objects with HMSET.
+// Keys are replaced and created in sorted order.
+func parseData(buf []byte) ([]redisCommand, error) {
+	buf, err := yaml.YAMLToJSON(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	values := make(map[string][]byte)
+	types := make(map[string]jsonparser.ValueType)
+
+	err = jsonparser.ObjectEach(buf, func(key []byte, val []byte, vt jsonparser.ValueType, _ int) error {
+		keys = append(keys, string(key))
+		values[string(key)] = val
+		types[string(key)] = vt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+
+	var commands []redisCommand
+	for _, key := range keys {
+		val, vt := values[key], types[key]
+
+		switch vt {
+		case jsonparser.String, jsonparser.Number, jsonparser.Boolean:
+			commands = append(commands, redisCommand{"SET", []interface{}{key, dataString(val, vt)}})
+
+		case jsonparser.Array:
+			args := []interface{}{key}
+			_, err := jsonparser.ArrayEach(val, func(elem []byte, vt jsonparser.ValueType, _ int, _ error) {
+				args = append(args, dataString(elem, vt))
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", key, err)
+			}
+			commands = append(commands, redisCommand{"DEL", []interface{}{key}})
+			if len(args) > 1 {
+				commands = append(commands, redisCommand{"RPUSH", args})
+			}
+
+		case jsonparser.Object:
+			args := []interface{}{key}
+			err := jsonparser.ObjectEach(val, func(field []byte, elem []byte, vt jsonparser.ValueType, _ int) error {
+				args = append(args, string(field), dataString(elem, vt))
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", key, err)
+			}
+			commands = append(commands, redisCommand{"DEL", []interface{}{key}})
+			if len(args) > 1 {
+				commands = append(commands, redisCommand{"HMSET", args})
+			}
+
+		default:
+			return nil, fmt.Errorf("%v: unsupported value", key)
+		}
+	}
+
+	return commands, nil
+}
+
+// dataString returns the redis representation of a document value.
+func dataString(val []byte, vt jsonparser.ValueType) string {
+	if vt == jsonparser.String {
+		if s, err := jsonparser.ParseString(val); err == nil {
+			return s
+		}
+	}
+	return string(val)
+}
@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommand(t *testing.T) {
+	words, err := splitCommand(`SET  greeting "hello \"world\"\n" ""`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SET", "greeting", "hello \"world\"\n", ""}, words)
+
+	_, err = splitCommand(`SET greeting "hello`)
+	assert.Error(t, err)
+}
+
+func TestParseCommands(t *testing.T) {
+	commands, err := parseCommands([]byte("# comment\n\nSET a 1\n  DEL a\n"))
+	require.NoError(t, err)
+	require.Len(t, commands, 2)
+	assert.Equal(t, "SET", commands[0].name)
+	assert.Equal(t, []interface{}{"a", "1"}, commands[0].args)
+	assert.Equal(t, "DEL", commands[1].name)
+}
+
+func TestParseData(t *testing.T) {
+	commands, err := parseData([]byte("b: [x, 1]\na: 2\nc:\n  f: v\n"))
+	require.NoError(t, err)
+
+	var strs []string
+	for _, cmd := range commands {
+		strs = append(strs, cmd.String())
+	}
+
+	assert.Equal(t, []string{
+		"SET a 2",
+		"DEL b",
+		"RPUSH b x 1",
+		"DEL c",
+		"HMSET c f v",
+	}, strs)
+
+	_, err = parseData([]byte("a: null\n"))
+	assert.Error(t, err)
+}
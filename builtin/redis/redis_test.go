@@ -56,3 +56,36 @@ func TestActionTruncate(t *testing.T) {
 		}()
 	})
 }
+
+func TestActionLoad(t *testing.T) {
+	for _, file := range []string{"pool.load.json", "pool.load.yaml"} {
+		testutil.WithPoolFromFile(t, file, func(pool ephemerald.Pool) {
+			for i := 0; i < 2; i++ {
+				func() {
+					p, err := pool.Checkout()
+					require.NoError(t, err, file)
+					defer pool.Return(p)
+
+					db, err := rredis.DialURL(p.Url)
+					require.NoError(t, err, file)
+					defer db.Close()
+
+					greeting, err := rredis.String(db.Do("GET", "greeting"))
+					require.NoError(t, err, file)
+					assert.Equal(t, `hello "world"`, greeting, file)
+
+					jobs, err := rredis.Strings(db.Do("LRANGE", "queue", 0, -1))
+					require.NoError(t, err, file)
+					assert.Equal(t, []string{"first job", "second job"}, jobs, file)
+
+					mode, err := rredis.String(db.Do("HGET", "config", "mode"))
+					require.NoError(t, err, file)
+					assert.Equal(t, "test", mode, file)
+
+					_, err = db.Do("RPUSH", "queue", "third job")
+					require.NoError(t, err, file)
+				}()
+			}
+		})
+	}
+}
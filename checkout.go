@@ -0,0 +1,32 @@
+package ephemerald
+
+import "time"
+
+type CheckoutOption func(*checkoutOptions)
+
+type checkoutOptions struct {
+	holder  string
+	timeout time.Duration
+}
+
+// WithHolder identifies the client that is checking out an item.
+func WithHolder(holder string) CheckoutOption {
+	return func(o *checkoutOptions) {
+		o.holder = holder
+	}
+}
+
+// WithTimeout limits how long to wait for an item to become available.
+func WithTimeout(timeout time.Duration) CheckoutOption {
+	return func(o *checkoutOptions) {
+		o.timeout = timeout
+	}
+}
+
+func newCheckoutOptions(opts []CheckoutOption) checkoutOptions {
+	o := checkoutOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
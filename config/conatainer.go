@@ -1,12 +1,17 @@
 package config
 
-import "github.com/docker/docker/api/types/strslice"
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/docker/docker/api/types/strslice"
+	units "github.com/docker/go-units"
+)
 
 type Container struct {
 	// docker/docker/api/types/container/config.go
-	Labels map[string]string
-
-	// unused
+	Labels     map[string]string
 	Env        []string
 	Cmd        strslice.StrSlice
 	Volumes    map[string]struct{}
@@ -16,6 +21,17 @@ type Container struct {
 	// docker/docker/api/types/container/host_config.go
 	CapAdd  strslice.StrSlice
 	CapDrop strslice.StrSlice
+
+	Memory     ByteSize          `json:"memory"`      // Memory limit
+	CPUs       float64           `json:"cpus"`        // Number of CPUs
+	CPUShares  int64             `json:"cpu_shares"`  // CPU shares (relative weight vs. other containers)
+	ShmSize    ByteSize          `json:"shm_size"`    // Size of /dev/shm
+	Tmpfs      map[string]string `json:"tmpfs"`       // tmpfs mounts: path -> mount options
+	Binds      []string          `json:"binds"`       // host-src:container-dest[:options]
+	Ulimits    map[string]Ulimit `json:"ulimits"`     // ulimits by name
+	Sysctls    map[string]string `json:"sysctls"`     // Namespaced sysctls
+	Privileged bool              `json:"privileged"`  // Run in privileged mode
+	ExtraHosts []string          `json:"extra_hosts"` // hostname:ip entries added to /etc/hosts
 }
 
 func NewContainer() *Container {
@@ -24,3 +40,75 @@ func NewContainer() *Container {
 		Volumes: make(map[string]struct{}),
 	}
 }
+
+// NanoCPUs returns the CPU quota in units of 10^-9 CPUs.
+func (c *Container) NanoCPUs() int64 {
+	return int64(c.CPUs * 1e9)
+}
+
+// DockerUlimits returns the configured ulimits, ordered by name.
+func (c *Container) DockerUlimits() []*units.Ulimit {
+	var names []string
+	for name := range c.Ulimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var ulimits []*units.Ulimit
+	for _, name := range names {
+		u := c.Ulimits[name]
+		ulimits = append(ulimits, &units.Ulimit{Name: name, Soft: u.Soft, Hard: u.Hard})
+	}
+	return ulimits
+}
+
+// ByteSize is a number of bytes given either as a number or
+// as a human-readable string such as "512m".
+type ByteSize int64
+
+func (b *ByteSize) UnmarshalJSON(buf []byte) error {
+	var num int64
+	if err := json.Unmarshal(buf, &num); err == nil {
+		*b = ByteSize(num)
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(buf, &str); err != nil {
+		return fmt.Errorf("invalid size: %s", buf)
+	}
+
+	num, err := units.RAMInBytes(str)
+	if err != nil {
+		return err
+	}
+	*b = ByteSize(num)
+	return nil
+}
+
+// Ulimit is given either as a single number, which sets both
+// limits, or as an object with "soft" and "hard" limits.
+type Ulimit struct {
+	Soft int64 `json:"soft"`
+	Hard int64 `json:"hard"`
+}
+
+func (u *Ulimit) UnmarshalJSON(buf []byte) error {
+	var num int64
+	if err := json.Unmarshal(buf, &num); err == nil {
+		u.Soft = num
+		u.Hard = num
+		return nil
+	}
+
+	type ulimit Ulimit
+	var val ulimit
+	if err := json.Unmarshal(buf, &val); err != nil {
+		return fmt.Errorf("invalid ulimit: %s", buf)
+	}
+	if val.Hard == 0 {
+		val.Hard = val.Soft
+	}
+	*u = Ulimit(val)
+	return nil
+}
@@ -1,12 +1,15 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"os"
 	"path"
+	"path/filepath"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/boz/ephemerald/lifecycle"
@@ -18,37 +21,75 @@ import (
 
 const (
 	maxSize = 200
+
+	defaultCooldown = time.Minute
+	defaultLeaseTTL = 0
 )
 
 type Config struct {
-	Name      string
-	Size      int
-	Image     string
-	Port      int
+	Name string
+
+	// identifies the configuration that a container was created
+	// with so that it can be adopted by later processes.
+	Hash string
+
+	// pool grows from MinSize to MaxSize items while
+	// clients are waiting for a checkout.
+	MinSize int
+	MaxSize int
+
+	// how long a ready item must be idle before it is
+	// killed to shrink the pool back towards MinSize.
+	Cooldown time.Duration
+
+	// checked-out items are reclaimed if their lease is not
+	// extended within LeaseTTL.  zero disables expiration.
+	LeaseTTL time.Duration
+
+	Image string
+
+	// primary container port
+	Port int
+
+	// container ports by name
+	Ports map[string]int
+
 	Container *Container
 	Params    params.Config
 	Lifecycle lifecycle.Manager
 
+	// set if the pool's containers are shared by many checkouts.
+	Shared *Shared
+
 	log logrus.FieldLogger
 
 	uie ui.PoolEmitter
 }
 
+// ReadFile reads the pools configured in fpath.  Relative file names
+// used by lifecycle actions are resolved against the directory of fpath.
 func ReadFile(log logrus.FieldLogger, uie ui.Emitter, fpath string) ([]*Config, error) {
-	file, err := os.Open(fpath)
-	if err != nil {
-		return []*Config{}, err
-	}
-	defer file.Close()
+	var configs []*Config
 
 	switch path.Ext(fpath) {
-	case ".yml", ".yaml":
-		return ReadYAML(log, uie, file)
-	case ".json":
-		return Read(log, uie, file)
+	case ".yml", ".yaml", ".json":
 	default:
 		return nil, fmt.Errorf("Unknown extension %v", path.Ext(fpath))
 	}
+
+	buf, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return configs, err
+	}
+
+	if path.Ext(fpath) != ".json" {
+		buf, err = yaml.YAMLToJSON(buf)
+		if err != nil {
+			return configs, err
+		}
+	}
+
+	return parseAll(log, uie, filepath.Dir(fpath), buf)
 }
 
 func Read(log logrus.FieldLogger, uie ui.Emitter, r io.Reader) ([]*Config, error) {
@@ -75,9 +116,13 @@ func ReadYAML(log logrus.FieldLogger, uie ui.Emitter, r io.Reader) ([]*Config, e
 }
 
 func ParseAll(log logrus.FieldLogger, uie ui.Emitter, buf []byte) ([]*Config, error) {
+	return parseAll(log, uie, "", buf)
+}
+
+func parseAll(log logrus.FieldLogger, uie ui.Emitter, dir string, buf []byte) ([]*Config, error) {
 	var configs []*Config
 	err := jsonparser.ObjectEach(buf, func(key []byte, buf []byte, dt jsonparser.ValueType, _ int) error {
-		config, err := Parse(log, uie, string(key), buf)
+		config, err := parse(log, uie, dir, string(key), buf)
 		if err != nil {
 			return err
 		}
@@ -88,17 +133,28 @@ func ParseAll(log logrus.FieldLogger, uie ui.Emitter, buf []byte) ([]*Config, er
 }
 
 func Parse(log logrus.FieldLogger, uie ui.Emitter, name string, buf []byte) (*Config, error) {
+	return parse(log, uie, "", name, buf)
+}
+
+func parse(log logrus.FieldLogger, uie ui.Emitter, dir string, name string, buf []byte) (*Config, error) {
 
 	log = log.WithField("pool", name).WithField("component", "config.Parse")
 
-	size, err := jsonparser.GetInt(buf, "size")
+	minSize, maxSize, err := parseSize(buf)
 	if err != nil {
 		log.WithError(err).Error("parsing size")
 		return nil, err
 	}
-	if size <= 0 || size >= maxSize {
-		err := fmt.Errorf("invalid pool size %v not in (0,%v)", size, maxSize)
-		log.WithError(err).Error("parsing size")
+
+	cooldown, err := getOptionalDuration(buf, "cooldown", defaultCooldown)
+	if err != nil {
+		log.WithError(err).Error("parsing cooldown")
+		return nil, err
+	}
+
+	leaseTTL, err := getOptionalDuration(buf, "lease", defaultLeaseTTL)
+	if err != nil {
+		log.WithError(err).Error("parsing lease")
 		return nil, err
 	}
 
@@ -108,9 +164,9 @@ func Parse(log logrus.FieldLogger, uie ui.Emitter, name string, buf []byte) (*Co
 		return nil, err
 	}
 
-	port, err := jsonparser.GetInt(buf, "port")
+	port, ports, err := parsePorts(buf)
 	if err != nil {
-		log.WithError(err).Error("parsing port")
+		log.WithError(err).Error("parsing ports")
 		return nil, err
 	}
 
@@ -150,25 +206,154 @@ func Parse(log logrus.FieldLogger, uie ui.Emitter, name string, buf []byte) (*Co
 		return nil, err
 	}
 
-	lifecycle := lifecycle.NewManager(log)
+	lifecycle := lifecycle.NewManagerInDir(log, dir)
 	if err := lifecycle.ParseConfig(actionBuf); err != nil {
 		log.WithError(err).Error("parsing lifecycle")
 		return nil, err
 	}
 
+	shared, err := parseShared(buf)
+	if err != nil {
+		log.WithError(err).Error("parsing shared")
+		return nil, err
+	}
+
+	if shared != nil {
+		if err := checkSharedActions(actionBuf); err != nil {
+			log.WithError(err).Error("invalid shared actions")
+			return nil, err
+		}
+	}
+
+	hash := sha256.Sum256(buf)
+
 	return &Config{
 		Name:      name,
-		Size:      int(size),
+		Hash:      hex.EncodeToString(hash[:]),
+		MinSize:   minSize,
+		MaxSize:   maxSize,
+		Cooldown:  cooldown,
+		LeaseTTL:  leaseTTL,
 		Image:     image,
-		Port:      int(port),
+		Port:      port,
+		Ports:     ports,
 		Container: cont,
 		Params:    params,
 		Lifecycle: lifecycle,
+		Shared:    shared,
 		log:       log,
 		uie:       uie.ForPool(name),
 	}, nil
 }
 
+// parseSize reads the pool bounds.  "size" fixes both bounds;
+// "min" and "max" override it.
+func parseSize(buf []byte) (int, int, error) {
+	size, hasSize, err := getOptionalInt(buf, "size")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	min, hasMin, err := getOptionalInt(buf, "min")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	max, hasMax, err := getOptionalInt(buf, "max")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !hasSize && !hasMax {
+		return 0, 0, fmt.Errorf("pool size not given")
+	}
+
+	if !hasMin {
+		min = size
+	}
+
+	if !hasMax {
+		max = size
+	}
+
+	if max <= 0 || max >= maxSize {
+		return 0, 0, fmt.Errorf("invalid pool size %v not in (0,%v)", max, maxSize)
+	}
+
+	if min < 0 || min > max {
+		return 0, 0, fmt.Errorf("invalid minimum pool size %v not in [0,%v]", min, max)
+	}
+
+	return int(min), int(max), nil
+}
+
+// parsePorts reads the primary port and the named ports.  The
+// primary port defaults to the lowest named port.
+func parsePorts(buf []byte) (int, map[string]int, error) {
+	port, hasPort, err := getOptionalInt(buf, "port")
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var ports map[string]int
+
+	portsBuf, vt, _, err := jsonparser.Get(buf, "ports")
+	switch {
+	case err == nil && vt == jsonparser.Object:
+		if err := json.Unmarshal(portsBuf, &ports); err != nil {
+			return 0, nil, err
+		}
+	case err == nil:
+		return 0, nil, fmt.Errorf("invalid ports type")
+	case err != jsonparser.KeyPathNotFoundError:
+		return 0, nil, err
+	}
+
+	lowest := 0
+	for name, p := range ports {
+		if p <= 0 {
+			return 0, nil, fmt.Errorf("invalid port %v for '%v'", p, name)
+		}
+		if lowest == 0 || p < lowest {
+			lowest = p
+		}
+	}
+
+	switch {
+	case hasPort:
+	case lowest > 0:
+		port = int64(lowest)
+	default:
+		return 0, nil, fmt.Errorf("port not given")
+	}
+
+	return int(port), ports, nil
+}
+
+func getOptionalInt(buf []byte, key string) (int64, bool, error) {
+	val, err := jsonparser.GetInt(buf, key)
+	switch {
+	case err == nil:
+		return val, true, nil
+	case err == jsonparser.KeyPathNotFoundError:
+		return 0, false, nil
+	default:
+		return 0, false, err
+	}
+}
+
+func getOptionalDuration(buf []byte, key string, def time.Duration) (time.Duration, error) {
+	val, err := jsonparser.GetString(buf, key)
+	switch {
+	case err == nil:
+		return time.ParseDuration(val)
+	case err == jsonparser.KeyPathNotFoundError:
+		return def, nil
+	default:
+		return 0, err
+	}
+}
+
 func (c Config) Log() logrus.FieldLogger {
 	return c.log
 }
@@ -2,8 +2,11 @@ package config_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/boz/ephemerald/config"
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
 	"github.com/boz/ephemerald/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -29,11 +32,207 @@ func doReadTest(t *testing.T, path string, msg string) {
 	assert.Equal(t, "redis", cfg.Name, msg)
 	assert.Equal(t, "redis", cfg.Image, msg)
 	assert.Equal(t, 6379, cfg.Port, msg)
-	assert.Equal(t, 10, cfg.Size, msg)
+	assert.Equal(t, 10, cfg.MinSize, msg)
+	assert.Equal(t, 10, cfg.MaxSize, msg)
 
-	m := cfg.Lifecycle.ForContainer(testutil.ContainerEmitter(), testutil.CID())
+	m := cfg.Lifecycle.ForContainer(testutil.ContainerEmitter(), testutil.Container())
 
 	assert.False(t, m.HasInitialize(), msg)
 	assert.True(t, m.HasHealthcheck(), msg)
 	assert.True(t, m.HasReset(), msg)
 }
+
+func TestParse_size(t *testing.T) {
+	log := testutil.Log()
+	uie := testutil.Emitter()
+
+	{
+		cfg, err := config.Parse(log, uie, "fixed", []byte(`{"image":"redis","port":6379,"size":5}`))
+		require.NoError(t, err)
+		assert.Equal(t, 5, cfg.MinSize)
+		assert.Equal(t, 5, cfg.MaxSize)
+		assert.Equal(t, time.Duration(0), cfg.LeaseTTL)
+	}
+
+	{
+		buf := []byte(`{"image":"redis","port":6379,"min":1,"max":20,"cooldown":"30s","lease":"2m"}`)
+		cfg, err := config.Parse(log, uie, "scaled", buf)
+		require.NoError(t, err)
+		assert.Equal(t, 1, cfg.MinSize)
+		assert.Equal(t, 20, cfg.MaxSize)
+		assert.Equal(t, 30*time.Second, cfg.Cooldown)
+		assert.Equal(t, 2*time.Minute, cfg.LeaseTTL)
+	}
+
+	{
+		cfg, err := config.Parse(log, uie, "idle", []byte(`{"image":"redis","port":6379,"min":0,"size":3}`))
+		require.NoError(t, err)
+		assert.Equal(t, 0, cfg.MinSize)
+		assert.Equal(t, 3, cfg.MaxSize)
+	}
+
+	for _, buf := range []string{
+		`{"image":"redis","port":6379}`,
+		`{"image":"redis","port":6379,"size":0}`,
+		`{"image":"redis","port":6379,"min":5,"max":2}`,
+		`{"image":"redis","port":6379,"size":2,"cooldown":"soon"}`,
+	} {
+		_, err := config.Parse(log, uie, "invalid", []byte(buf))
+		assert.Error(t, err, buf)
+	}
+}
+
+func TestParse_hash(t *testing.T) {
+	log := testutil.Log()
+	uie := testutil.Emitter()
+
+	buf := []byte(`{"image":"redis","port":6379,"size":5}`)
+
+	a, err := config.Parse(log, uie, "redis", buf)
+	require.NoError(t, err)
+	assert.NotEmpty(t, a.Hash)
+
+	b, err := config.Parse(log, uie, "redis", buf)
+	require.NoError(t, err)
+	assert.Equal(t, a.Hash, b.Hash)
+
+	c, err := config.Parse(log, uie, "redis", []byte(`{"image":"redis","port":6379,"size":6}`))
+	require.NoError(t, err)
+	assert.NotEqual(t, a.Hash, c.Hash)
+}
+
+func TestParse_container(t *testing.T) {
+	log := testutil.Log()
+	uie := testutil.Emitter()
+
+	buf := []byte(`{
+		"image": "postgres",
+		"port": 5432,
+		"size": 1,
+		"container": {
+			"entrypoint": ["docker-entrypoint.sh"],
+			"user": "postgres",
+			"capadd": ["IPC_LOCK"],
+			"memory": "512m",
+			"cpus": 1.5,
+			"shm_size": 268435456,
+			"tmpfs": {"/var/lib/postgresql/data": "rw,size=1g"},
+			"binds": ["/tmp/init:/docker-entrypoint-initdb.d:ro"],
+			"ulimits": {"nofile": {"soft": 1024, "hard": 2048}, "nproc": 512},
+			"sysctls": {"net.core.somaxconn": "1024"},
+			"privileged": true,
+			"extra_hosts": ["db.local:127.0.0.1"]
+		}
+	}`)
+
+	cfg, err := config.Parse(log, uie, "postgres", buf)
+	require.NoError(t, err)
+
+	c := cfg.Container
+	assert.Equal(t, []string{"docker-entrypoint.sh"}, []string(c.Entrypoint))
+	assert.Equal(t, "postgres", c.User)
+	assert.Equal(t, []string{"IPC_LOCK"}, []string(c.CapAdd))
+	assert.Equal(t, config.ByteSize(512*1024*1024), c.Memory)
+	assert.Equal(t, int64(1500000000), c.NanoCPUs())
+	assert.Equal(t, config.ByteSize(268435456), c.ShmSize)
+	assert.Equal(t, "rw,size=1g", c.Tmpfs["/var/lib/postgresql/data"])
+	assert.Equal(t, []string{"/tmp/init:/docker-entrypoint-initdb.d:ro"}, c.Binds)
+	assert.Equal(t, "1024", c.Sysctls["net.core.somaxconn"])
+	assert.True(t, c.Privileged)
+	assert.Equal(t, []string{"db.local:127.0.0.1"}, c.ExtraHosts)
+
+	ulimits := c.DockerUlimits()
+	require.Len(t, ulimits, 2)
+	assert.Equal(t, "nofile", ulimits[0].Name)
+	assert.Equal(t, int64(1024), ulimits[0].Soft)
+	assert.Equal(t, int64(2048), ulimits[0].Hard)
+	assert.Equal(t, "nproc", ulimits[1].Name)
+	assert.Equal(t, int64(512), ulimits[1].Soft)
+	assert.Equal(t, int64(512), ulimits[1].Hard)
+
+	_, err = config.Parse(log, uie, "postgres", []byte(`{"image":"postgres","port":5432,"size":1,"container":{"memory":"lots"}}`))
+	assert.Error(t, err)
+}
+
+func TestParse_ports(t *testing.T) {
+	log := testutil.Log()
+	uie := testutil.Emitter()
+
+	{
+		cfg, err := config.Parse(log, uie, "minio", []byte(`{"image":"minio","size":1,"ports":{"api":9000,"console":9001}}`))
+		require.NoError(t, err)
+		assert.Equal(t, 9000, cfg.Port)
+		assert.Equal(t, map[string]int{"api": 9000, "console": 9001}, cfg.Ports)
+	}
+
+	{
+		cfg, err := config.Parse(log, uie, "minio", []byte(`{"image":"minio","size":1,"port":9001,"ports":{"api":9000}}`))
+		require.NoError(t, err)
+		assert.Equal(t, 9001, cfg.Port)
+	}
+
+	for _, buf := range []string{
+		`{"image":"minio","size":1}`,
+		`{"image":"minio","size":1,"ports":[9000]}`,
+		`{"image":"minio","size":1,"ports":{"api":0}}`,
+	} {
+		_, err := config.Parse(log, uie, "invalid", []byte(buf))
+		assert.Error(t, err, buf)
+	}
+}
+
+func TestParse_shared(t *testing.T) {
+	log := testutil.Log()
+	uie := testutil.Emitter()
+
+	{
+		cfg, err := config.Parse(log, uie, "exclusive", []byte(`{"image":"postgres","port":5432,"size":1}`))
+		require.NoError(t, err)
+		assert.Nil(t, cfg.Shared)
+	}
+
+	{
+		buf := []byte(`{"image":"postgres","port":5432,"size":2,"shared":{"type":"test.shared","capacity":25,"timeout":"30s"}}`)
+		cfg, err := config.Parse(log, uie, "shared", buf)
+		require.NoError(t, err)
+		require.NotNil(t, cfg.Shared)
+		assert.Equal(t, 25, cfg.Shared.Capacity)
+		assert.Equal(t, 30*time.Second, cfg.Shared.Timeout)
+		assert.IsType(t, testProvider{}, cfg.Shared.Provider)
+	}
+
+	{
+		cfg, err := config.Parse(log, uie, "shared", []byte(`{"image":"postgres","port":5432,"size":2,"shared":{"type":"test.shared"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, 10, cfg.Shared.Capacity)
+	}
+
+	for _, buf := range []string{
+		`{"image":"postgres","port":5432,"size":1,"shared":true}`,
+		`{"image":"postgres","port":5432,"size":1,"shared":{}}`,
+		`{"image":"postgres","port":5432,"size":1,"shared":{"type":"unknown"}}`,
+		`{"image":"postgres","port":5432,"size":1,"shared":{"type":"test.shared","capacity":0}}`,
+		`{"image":"postgres","port":5432,"size":1,"shared":{"type":"test.shared"},"actions":{"on-checkout":{"type":"noop"}}}`,
+		`{"image":"postgres","port":5432,"size":1,"shared":{"type":"test.shared"},"actions":{"on-return":{"type":"noop"}}}`,
+	} {
+		_, err := config.Parse(log, uie, "invalid", []byte(buf))
+		assert.Error(t, err, buf)
+	}
+}
+
+func init() {
+	lifecycle.MakeDatabaseProviderPlugin("test.shared", func([]byte) (lifecycle.DatabaseProvider, error) {
+		return testProvider{}, nil
+	})
+}
+
+type testProvider struct{}
+
+func (testProvider) Create(_ lifecycle.Env, server params.Params, name string) (params.Params, error) {
+	server.Database = name
+	return server, nil
+}
+
+func (testProvider) Drop(lifecycle.Env, params.Params, params.Params) error {
+	return nil
+}
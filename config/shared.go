@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/buger/jsonparser"
+)
+
+const (
+	defaultSharedCapacity = 10
+	defaultSharedTimeout  = 10 * time.Second
+)
+
+// Shared configures a pool whose containers are shared by many
+// checkouts.  Each checkout receives its own database inside one
+// of the pool's containers.
+type Shared struct {
+	// maximum number of databases in each container.
+	Capacity int
+
+	// how long to allow for creating or dropping a database.
+	Timeout time.Duration
+
+	// creates and drops the databases.
+	Provider lifecycle.DatabaseProvider
+}
+
+// parseShared reads the "shared" section.  nil is returned
+// if the pool is not shared.
+func parseShared(buf []byte) (*Shared, error) {
+	buf, vt, _, err := jsonparser.Get(buf, "shared")
+	switch {
+	case err == jsonparser.KeyPathNotFoundError:
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case vt != jsonparser.Object:
+		return nil, fmt.Errorf("invalid shared type")
+	}
+
+	capacity, hasCapacity, err := getOptionalInt(buf, "capacity")
+	if err != nil {
+		return nil, err
+	}
+	if !hasCapacity {
+		capacity = defaultSharedCapacity
+	}
+	if capacity <= 0 {
+		return nil, fmt.Errorf("invalid shared capacity %v", capacity)
+	}
+
+	timeout, err := getOptionalDuration(buf, "timeout", defaultSharedTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := lifecycle.ParseDatabaseProvider(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Shared{
+		Capacity: int(capacity),
+		Timeout:  timeout,
+		Provider: provider,
+	}, nil
+}
+
+// checkSharedActions rejects the actions that can't be run for a
+// shared pool: its clients check out databases, not containers.
+func checkSharedActions(buf []byte) error {
+	for _, name := range []string{"on-checkout", "on-return"} {
+		if _, _, _, err := jsonparser.Get(buf, name); err == nil {
+			return fmt.Errorf("%v actions are not supported with shared containers", name)
+		}
+	}
+	return nil
+}
@@ -1,22 +1,25 @@
 package ephemerald
 
 import (
+	"context"
 	"io"
-	"os"
 	"strconv"
 	"sync"
 	"syscall"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/boz/ephemerald/lifecycle"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 )
 
 type poolContainer interface {
 	StatusItem
+	lifecycle.Container
 	start()
 	stop()
 	events() <-chan containerEvent
+	logs() *logBuffer
 }
 
 type containerEvent string
@@ -33,8 +36,14 @@ type pcontainer struct {
 
 	id string
 
+	// adopted containers are already running
+	adopted bool
+
 	status types.ContainerJSON
 
+	// recent output
+	logbuf *logBuffer
+
 	eventch chan containerEvent
 
 	done chan interface{}
@@ -55,10 +64,23 @@ func createPoolContainer(log logrus.FieldLogger, adapter dockerAdapter) (poolCon
 		return nil, err
 	}
 
+	return newPoolContainer(log, adapter, cid, false), nil
+}
+
+// adoptPoolContainer manages an already-running container.
+func adoptPoolContainer(log logrus.FieldLogger, adapter dockerAdapter, cid string) poolContainer {
+	log = log.WithField("component", "pool-container")
+	return newPoolContainer(log, adapter, cid, true)
+}
+
+func newPoolContainer(log logrus.FieldLogger, adapter dockerAdapter, cid string, adopted bool) poolContainer {
 	c := &pcontainer{
 		adapter: adapter,
 
-		id: cid,
+		id:      cid,
+		adopted: adopted,
+
+		logbuf: newLogBuffer(containerLogSize),
 
 		eventch: make(chan containerEvent),
 
@@ -66,11 +88,9 @@ func createPoolContainer(log logrus.FieldLogger, adapter dockerAdapter) (poolCon
 		log:  lcid(log, cid),
 	}
 
-	go c.dumpLogs()
-
 	go c.monitor()
 
-	return c, nil
+	return c
 }
 
 func (c *pcontainer) ID() string {
@@ -85,6 +105,69 @@ func (c *pcontainer) events() <-chan containerEvent {
 	return c.eventch
 }
 
+func (c *pcontainer) Exec(ctx context.Context, cmd []string, env []string, stdout, stderr io.Writer) (int, error) {
+	return c.adapter.containerExec(ctx, c.id, cmd, env, stdout, stderr)
+}
+
+func (c *pcontainer) CopyTo(ctx context.Context, dest string, content io.Reader) error {
+	return c.adapter.containerCopyTo(ctx, c.id, dest, content)
+}
+
+func (c *pcontainer) Health(ctx context.Context) (lifecycle.Health, error) {
+	status, err := c.adapter.containerInspectWith(ctx, c.id)
+	if err != nil {
+		return lifecycle.Health{}, err
+	}
+
+	if status.ContainerJSONBase == nil || status.State == nil || status.State.Health == nil {
+		return lifecycle.Health{Status: lifecycle.HealthNone}, nil
+	}
+
+	health := lifecycle.Health{Status: status.State.Health.Status}
+	if log := status.State.Health.Log; len(log) > 0 {
+		health.Output = log[len(log)-1].Output
+	}
+	return health, nil
+}
+
+// Logs reads the container's output from docker rather than the log
+// buffer so that output which no longer fits in the buffer is included.
+func (c *pcontainer) Logs(ctx context.Context) (io.ReadCloser, error) {
+	options := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+	}
+
+	body, err := c.adapter.containerLogs(c.id, options)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		if c.status.Config != nil && c.status.Config.Tty {
+			_, err = io.Copy(pw, body)
+		} else {
+			err = demuxLogs(pw, body)
+		}
+		pw.CloseWithError(err)
+	}()
+
+	go func() {
+		<-ctx.Done()
+		pr.Close()
+		body.Close()
+	}()
+
+	return pr, nil
+}
+
+func (c *pcontainer) logs() *logBuffer {
+	return c.logbuf
+}
+
 func (c *pcontainer) start() {
 	c.startOnce.Do(func() {
 		go c.doStart()
@@ -98,9 +181,11 @@ func (c *pcontainer) stop() {
 }
 
 func (c *pcontainer) doStart() {
-	if err := c.startContainer(); err != nil {
-		c.eventch <- containerEventStartFailed
-		return
+	if !c.adopted {
+		if err := c.startContainer(); err != nil {
+			c.eventch <- containerEventStartFailed
+			return
+		}
 	}
 
 	status, err := c.getStatus()
@@ -111,6 +196,8 @@ func (c *pcontainer) doStart() {
 
 	c.status = status
 
+	go c.captureLogs()
+
 	c.eventch <- containerEventStarted
 }
 
@@ -191,27 +278,33 @@ func (c *pcontainer) doMonitor() error {
 	}
 }
 
-func (c *pcontainer) dumpLogs() {
-
-	c.log.Debug("dumping logs")
+// captureLogs copies the output of the container into
+// its log buffer until the container exits.
+func (c *pcontainer) captureLogs() {
+	defer c.logbuf.Close()
 
 	options := types.ContainerLogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
-		Details:    true,
-		Tail:       "1",
+		Follow:     true,
 	}
 
 	body, err := c.adapter.containerLogs(c.id, options)
 	if err != nil {
 		c.log.WithError(err).Error("error getting logs")
+		return
 	}
 	defer body.Close()
 
-	_, err = io.Copy(os.Stdout, body)
+	if c.status.Config != nil && c.status.Config.Tty {
+		_, err = io.Copy(c.logbuf, body)
+	} else {
+		err = demuxLogs(c.logbuf, body)
+	}
+
 	if err != nil {
 		c.log.WithError(err).Error("reading logs")
 	}
 
-	c.log.Debug("done dumping logs")
+	c.log.Debug("done reading logs")
 }
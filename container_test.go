@@ -0,0 +1,65 @@
+package ephemerald
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolContainer_logs(t *testing.T) {
+	// more output than the log buffer holds between matches.
+	expected := "ready\n" + strings.Repeat("filler\n", 2*containerLogSize/7) + "ready\n"
+
+	in := new(bytes.Buffer)
+	for output := expected; len(output) > 0; {
+		n := len(output)
+		if n > 4096 {
+			n = 4096
+		}
+		header := make([]byte, 8)
+		header[0] = 1
+		binary.BigEndian.PutUint32(header[4:], uint32(n))
+		in.Write(header)
+		in.WriteString(output[:n])
+		output = output[n:]
+	}
+
+	adapter := logsAdapter{testAdapter{log: logrus.New()}, in.Bytes()}
+	c := &pcontainer{adapter: adapter, id: "logs00000000"}
+
+	action, err := lifecycle.ParseAction([]byte(`{"type":"log.match","pattern":"^ready$","count":2}`))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	env := lifecycle.NewContainerEnv(ctx, logrus.New(), c)
+	assert.NoError(t, action.Do(env, params.Params{}))
+
+	r, err := c.Logs(ctx)
+	require.NoError(t, err)
+	buf, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(buf))
+}
+
+// logsAdapter returns the given docker log stream.
+type logsAdapter struct {
+	testAdapter
+	body []byte
+}
+
+func (a logsAdapter) containerLogs(string, types.ContainerLogsOptions) (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(a.body)), nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"strconv"
@@ -13,6 +14,9 @@ import (
 	"github.com/boz/ephemerald/net"
 	"github.com/boz/ephemerald/ui"
 
+	_ "github.com/boz/ephemerald/builtin/migrate"
+	_ "github.com/boz/ephemerald/builtin/mongo"
+	_ "github.com/boz/ephemerald/builtin/mysql"
 	_ "github.com/boz/ephemerald/builtin/postgres"
 	_ "github.com/boz/ephemerald/builtin/redis"
 
@@ -25,9 +29,14 @@ var (
 			Int()
 
 	configFile = kingpin.Flag("config", "config file").Short('c').
-			Required().
 			ExistingFile()
 
+	cleanupOrphans = kingpin.Flag("cleanup-orphans", "Kill containers left running by exited servers on this host and exit").
+			Bool()
+
+	orphansAnyHost = kingpin.Flag("orphans-any-host", "Treat containers created by servers on other hosts as orphans").
+			Bool()
+
 	logLevel = kingpin.Flag("log-level", "Log level (debug, info, warn, error).  Default: info").
 			Default("info").
 			Enum("debug", "info", "warn", "error")
@@ -51,6 +60,21 @@ func main() {
 	log.Level = level
 	log.Out = *logFile
 
+	ephemerald.SetOrphansAnyHost(*orphansAnyHost)
+
+	if *cleanupOrphans {
+		ids, err := ephemerald.CleanupOrphans(log)
+		for _, id := range ids {
+			fmt.Println(id)
+		}
+		kingpin.FatalIfError(err, "cleaning up orphans")
+		return
+	}
+
+	if *configFile == "" {
+		kingpin.Fatalf("required flag --config not provided")
+	}
+
 	ctx := context.Background()
 
 	uishutdown := make(chan bool)
@@ -77,6 +101,7 @@ func main() {
 
 	builder.WithPort(*listenPort)
 	builder.WithPoolSet(pools)
+	builder.WithStatus(appui.Status())
 
 	server, err := builder.Create()
 	if err != nil {
@@ -31,6 +31,18 @@ type poolItem interface {
 	start()
 	reset()
 	kill()
+	logs() *logBuffer
+
+	// actions returns the lifecycle actions bound to
+	// the item's container.
+	actions() lifecycle.ContainerManager
+
+	// done is closed once the item has exited.
+	done() <-chan bool
+
+	// checkout runs the on-checkout hook before the
+	// item is handed to a client.
+	checkout(ctx context.Context) error
 }
 
 type pitem struct {
@@ -38,6 +50,17 @@ type pitem struct {
 	adapter   dockerAdapter
 	container poolContainer
 
+	// adopted items are reset instead of initialized
+	// once they pass their healthcheck.
+	adopted bool
+
+	// set once the container has started; pre-stop
+	// hooks are only run on started containers.
+	started bool
+
+	// set once the item has begun stopping.
+	stopping bool
+
 	events chan poolItemEvent
 	joinch chan (chan<- poolEvent)
 
@@ -63,6 +86,18 @@ func createPoolItem(uie ui.PoolEmitter, log logrus.FieldLogger, adapter dockerAd
 		return nil, err
 	}
 
+	return newPoolItem(uie, log, adapter, lifecycle, container, false), nil
+}
+
+// adoptPoolItem creates an item for a container that was left
+// running by a previous ephemerald process.
+func adoptPoolItem(uie ui.PoolEmitter, log logrus.FieldLogger, adapter dockerAdapter, lifecycle lifecycle.Manager, cid string) poolItem {
+	log = log.WithField("component", "pool-item")
+	container := adoptPoolContainer(log, adapter, cid)
+	return newPoolItem(uie, log, adapter, lifecycle, container, true)
+}
+
+func newPoolItem(uie ui.PoolEmitter, log logrus.FieldLogger, adapter dockerAdapter, lifecycle lifecycle.Manager, container poolContainer, adopted bool) poolItem {
 	log = lcid(log, container.ID())
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -72,9 +107,10 @@ func createPoolItem(uie ui.PoolEmitter, log logrus.FieldLogger, adapter dockerAd
 	cuie.EmitCreated()
 
 	item := &pitem{
-		lifecycle: lifecycle.ForContainer(cuie, container.ID()),
+		lifecycle: lifecycle.ForContainer(cuie, container),
 		adapter:   adapter,
 		container: container,
+		adopted:   adopted,
 		events:    make(chan poolItemEvent),
 		joinch:    make(chan (chan<- poolEvent)),
 		exited:    make(chan bool),
@@ -86,7 +122,7 @@ func createPoolItem(uie ui.PoolEmitter, log logrus.FieldLogger, adapter dockerAd
 
 	go item.run()
 
-	return item, nil
+	return item
 }
 
 func (i *pitem) ID() string {
@@ -97,6 +133,18 @@ func (i *pitem) Status() types.ContainerJSON {
 	return i.container.Status()
 }
 
+func (i *pitem) logs() *logBuffer {
+	return i.container.logs()
+}
+
+func (i *pitem) actions() lifecycle.ContainerManager {
+	return i.lifecycle
+}
+
+func (i *pitem) done() <-chan bool {
+	return i.exited
+}
+
 func (i *pitem) join(ch chan<- poolEvent) {
 	i.joinch <- ch
 }
@@ -113,6 +161,37 @@ func (i *pitem) kill() {
 	go i.sendEvent(eventPoolItemKill)
 }
 
+// checkout runs the on-checkout hook.  It is run by the client
+// checking out the item and is cancelled if the item exits.
+func (i *pitem) checkout(ctx context.Context) error {
+	if !i.lifecycle.HasOnCheckout() {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-i.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	params, err := i.currentParams()
+	if err != nil {
+		return err
+	}
+
+	if err := i.lifecycle.DoOnCheckout(ctx, params); err != nil {
+		err = i.lifecycleFailed("on-checkout", err)
+		i.log.WithError(err).Error("error checking out")
+		return err
+	}
+	return nil
+}
+
 func (i *pitem) sendEvent(e poolItemEvent) {
 	select {
 	case <-i.exited:
@@ -167,9 +246,10 @@ func (i *pitem) runMainLoop(ch chan<- poolEvent) {
 			case containerEventStartFailed:
 				i.log.Info("container exited")
 				i.uie.EmitExited()
-				ch <- poolEvent{eventItemExit, i}
+				ch <- poolEvent{eventItemExit, i, ""}
 				return
 			case containerEventStarted:
+				i.started = true
 				i.uie.EmitStarted()
 				i.do(i.onChildStarted)
 			}
@@ -179,31 +259,53 @@ func (i *pitem) runMainLoop(ch chan<- poolEvent) {
 
 			switch e {
 			case eventPoolItemKill:
-				i.uie.EmitExiting()
-				i.container.stop()
+				i.stop()
 			case eventPoolItemStart:
 				i.container.start()
 			case eventPoolItemLive:
 				i.uie.EmitLive()
-				i.do(i.onChildLive)
+				if i.adopted {
+					// state left by the previous process is unknown.
+					i.uie.EmitResetting()
+					i.do(i.onChildReset)
+				} else {
+					i.do(i.onChildLive)
+				}
 			case eventPoolItemLiveError:
-				i.uie.EmitExiting()
-				i.container.stop()
+				i.stop()
 			case eventPoolItemReady:
 				i.uie.EmitReady()
-				ch <- poolEvent{eventItemReady, i}
+				ch <- poolEvent{eventItemReady, i, ""}
 			case eventPoolItemReadyError:
-				i.uie.EmitExiting()
-				i.container.stop()
+				i.stop()
 			case eventPoolItemReset:
 				i.uie.EmitResetting()
-				i.do(i.onChildReset)
+				i.do(i.onChildReturn)
+			case eventPoolItemResetError:
+				i.stop()
 			}
 
 		}
 	}
 }
 
+// stop kills the container, running the pre-stop
+// hook first if the container has started.
+func (i *pitem) stop() {
+	if i.stopping {
+		return
+	}
+	i.stopping = true
+	i.uie.EmitExiting()
+
+	if !i.started || !i.lifecycle.HasPreStop() {
+		i.container.stop()
+		return
+	}
+
+	i.do(i.onChildStop)
+}
+
 func (i *pitem) drain() {
 	log := i.log.WithField("method", "drain")
 
@@ -232,6 +334,7 @@ func (i *pitem) onChildStarted() {
 			return
 		}
 		if err := i.lifecycle.DoHealthcheck(i.ctx, params); err != nil {
+			err = i.lifecycleFailed("healthcheck", err)
 			i.log.WithError(err).Error("error checking liveliness")
 			i.events <- eventPoolItemLiveError
 			return
@@ -248,6 +351,7 @@ func (i *pitem) onChildLive() {
 			return
 		}
 		if err := i.lifecycle.DoInitialize(i.ctx, params); err != nil {
+			err = i.lifecycleFailed("initialize", err)
 			i.log.WithError(err).Error("error initializing")
 			i.events <- eventPoolItemReadyError
 			return
@@ -257,6 +361,23 @@ func (i *pitem) onChildLive() {
 	i.events <- eventPoolItemReady
 }
 
+func (i *pitem) onChildReturn() {
+	if i.lifecycle.HasOnReturn() {
+		params, err := i.currentParams()
+		if err != nil {
+			i.events <- eventPoolItemResetError
+			return
+		}
+		if err := i.lifecycle.DoOnReturn(i.ctx, params); err != nil {
+			err = i.lifecycleFailed("on-return", err)
+			i.log.WithError(err).Error("error returning")
+			i.events <- eventPoolItemResetError
+			return
+		}
+	}
+	i.onChildReset()
+}
+
 func (i *pitem) onChildReset() {
 	if i.lifecycle.HasReset() {
 		params, err := i.currentParams()
@@ -272,7 +393,34 @@ func (i *pitem) onChildReset() {
 		i.events <- eventPoolItemReady
 		return
 	}
-	i.container.stop()
+	i.events <- eventPoolItemKill
+}
+
+// onChildStop runs the pre-stop hook.  The container
+// is killed whether or not the hook succeeds.
+func (i *pitem) onChildStop() {
+	defer i.container.stop()
+
+	params, err := i.currentParams()
+	if err != nil {
+		return
+	}
+	if err := i.lifecycle.DoPreStop(i.ctx, params); err != nil {
+		err = i.lifecycleFailed("pre-stop", err)
+		i.log.WithError(err).Error("error running pre-stop")
+	}
+}
+
+// lifecycleFailed reports actions that failed, either by exhausting
+// their retries or with an unrecoverable error, along with the
+// tail of the container's output.
+func (i *pitem) lifecycleFailed(name string, err error) error {
+	if i.ctx.Err() != nil {
+		return err
+	}
+	err = errorWithLogs(err, i.logs())
+	i.uie.EmitLifecycleFailed(name, err)
+	return err
 }
 
 func (i *pitem) currentParams() (params.Params, error) {
@@ -0,0 +1,40 @@
+package ephemerald
+
+import "time"
+
+// Lease describes an item that is checked out of a pool.
+// Expires is zero if the lease never expires.
+type Lease struct {
+	ID      string    `json:"id"`
+	Holder  string    `json:"holder,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+type poolLease struct {
+	item poolItem
+	ttl  time.Duration
+	Lease
+}
+
+func newPoolLease(item poolItem, holder string, ttl time.Duration) *poolLease {
+	l := &poolLease{
+		item: item,
+		ttl:  ttl,
+		Lease: Lease{
+			ID:     item.ID(),
+			Holder: holder,
+		},
+	}
+	l.extend()
+	return l
+}
+
+func (l *poolLease) extend() {
+	if l.ttl > 0 {
+		l.Expires = time.Now().Add(l.ttl)
+	}
+}
+
+func (l *poolLease) expired(now time.Time) bool {
+	return l.ttl > 0 && now.After(l.Expires)
+}
@@ -0,0 +1,54 @@
+package ephemerald
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool_leaseExpired(t *testing.T) {
+	_, pool := startTestPool(t, `"size":1,"lease":"10ms"`, 0)
+	defer pool.Stop()
+
+	p, err := pool.Checkout(WithHolder("test"))
+	require.NoError(t, err)
+
+	lease, err := pool.Heartbeat(p)
+	require.NoError(t, err)
+	assert.Equal(t, p.Id, lease.ID)
+	assert.Equal(t, "test", lease.Holder)
+	assert.False(t, lease.Expires.IsZero())
+
+	// reclaimed on a later tick.
+	deadline := time.Now().Add(3 * poolTickInterval)
+	for err == nil && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		_, err = pool.Heartbeat(p)
+	}
+	assert.Equal(t, ErrNotCheckedOut, err)
+	assert.Equal(t, ErrNotCheckedOut, pool.Return(p))
+
+	next, err := pool.Checkout()
+	require.NoError(t, err)
+	assert.Equal(t, p.Id, next.Id)
+}
+
+func TestPool_leaseDisabled(t *testing.T) {
+	_, pool := startTestPool(t, `"size":1`, 0)
+	defer pool.Stop()
+
+	p, err := pool.Checkout()
+	require.NoError(t, err)
+
+	lease, err := pool.Heartbeat(p)
+	require.NoError(t, err)
+	assert.True(t, lease.Expires.IsZero())
+
+	time.Sleep(poolTickInterval + 100*time.Millisecond)
+
+	_, err = pool.Heartbeat(p)
+	assert.NoError(t, err)
+	assert.NoError(t, pool.Return(p))
+}
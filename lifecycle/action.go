@@ -29,6 +29,7 @@ type ActionConfig struct {
 	Retries int
 	Timeout time.Duration
 	Delay   time.Duration
+	Backoff Backoff
 }
 
 func (ac ActionConfig) Config() ActionConfig {
@@ -67,6 +68,7 @@ func (ac *ActionConfig) UnmarshalJSON(buf []byte) error {
 		Retries int
 		Timeout string
 		Delay   string
+		Backoff *json.RawMessage
 	}{Retries: ac.Retries}
 
 	err := json.Unmarshal(buf, &other)
@@ -93,6 +95,12 @@ func (ac *ActionConfig) UnmarshalJSON(buf []byte) error {
 		ac.Delay = val
 	}
 
+	if other.Backoff != nil {
+		if err := json.Unmarshal(*other.Backoff, &ac.Backoff); err != nil {
+			return parseError("backoff", err)
+		}
+	}
+
 	return nil
 }
 
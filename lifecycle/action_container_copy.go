@@ -0,0 +1,192 @@
+package lifecycle
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+)
+
+const (
+	actionContainerCopyDefaultTimeout = time.Second * 10
+)
+
+func init() {
+	MakeActionPlugin("container.copy", actionContainerCopyParse)
+}
+
+// actionContainerCopy copies a file or directory from the
+// host into the container using the docker copy API.
+type actionContainerCopy struct {
+	ActionConfig
+
+	// file or directory on the host.  relative to
+	// the configuration file.
+	Src string
+
+	// existing directory in the container
+	Dest string
+
+	// render files as templates
+	Template bool
+}
+
+func actionContainerCopyParse(buf []byte) (Action, error) {
+	ac := DefaultActionConfig()
+	ac.Timeout = actionContainerCopyDefaultTimeout
+
+	action := &actionContainerCopy{
+		ActionConfig: ac,
+	}
+
+	if err := json.Unmarshal(buf, action); err != nil {
+		return nil, err
+	}
+
+	{
+		val, err := jsonparser.GetString(buf, "src")
+		switch {
+		case err == nil:
+			action.Src = val
+		case err == jsonparser.KeyPathNotFoundError:
+			return nil, fmt.Errorf("container.copy: no src given")
+		default:
+			return nil, err
+		}
+	}
+
+	{
+		val, err := jsonparser.GetString(buf, "dest")
+		switch {
+		case err == nil:
+			action.Dest = val
+		case err == jsonparser.KeyPathNotFoundError:
+			return nil, fmt.Errorf("container.copy: no dest given")
+		default:
+			return nil, err
+		}
+	}
+
+	{
+		val, err := jsonparser.GetBoolean(buf, "template")
+		switch {
+		case err == nil:
+			action.Template = val
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	return action, nil
+}
+
+func (a *actionContainerCopy) Do(e Env, p params.Params) error {
+	container := e.Container()
+	if container == nil {
+		return fmt.Errorf("container.copy: no container")
+	}
+
+	src := e.Path(a.Src)
+
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.CloseWithError(a.writeArchive(pw, src, p))
+	}()
+
+	err := container.CopyTo(e.Context(), a.Dest, pr)
+	pr.CloseWithError(err)
+	return err
+}
+
+// writeArchive writes a tar archive of src.  Directories are archived
+// relative to src so that their contents are extracted into Dest.
+func (a *actionContainerCopy) writeArchive(w io.Writer, src string, p params.Params) error {
+	tw := tar.NewWriter(w)
+
+	root := filepath.Clean(src)
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		if name == "." {
+			if info.IsDir() {
+				return nil
+			}
+			name = filepath.Base(path)
+		}
+
+		return a.writeEntry(tw, p, path, filepath.ToSlash(name), info)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+func (a *actionContainerCopy) writeEntry(tw *tar.Writer, p params.Params, path string, name string, info os.FileInfo) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    int64(info.Mode().Perm()),
+		ModTime: info.ModTime(),
+	}
+
+	switch {
+	case info.IsDir():
+		header.Typeflag = tar.TypeDir
+		header.Name += "/"
+		return tw.WriteHeader(header)
+	case !info.Mode().IsRegular():
+		// skip symlinks, sockets, etc.
+		return nil
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if a.Template {
+		tmpl, err := template.New(name).Parse(string(buf))
+		if err != nil {
+			return err
+		}
+		out, err := p.ExecuteTemplate(tmpl)
+		if err != nil {
+			return err
+		}
+		buf = []byte(out)
+	}
+
+	header.Typeflag = tar.TypeReg
+	header.Size = int64(len(buf))
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err = tw.Write(buf)
+	return err
+}
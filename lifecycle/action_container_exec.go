@@ -0,0 +1,134 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+)
+
+const (
+	actionContainerExecDefaultTimeout = time.Second * 5
+)
+
+func init() {
+	MakeActionPlugin("container.exec", actionContainerExecParse)
+}
+
+// actionContainerExec runs a command inside of the
+// container using the docker exec API.
+type actionContainerExec struct {
+	ActionConfig
+	Cmd []string
+	Env []string
+}
+
+func actionContainerExecParse(buf []byte) (Action, error) {
+	ac := DefaultActionConfig()
+	ac.Timeout = actionContainerExecDefaultTimeout
+
+	action := &actionContainerExec{
+		ActionConfig: ac,
+	}
+
+	if err := json.Unmarshal(buf, action); err != nil {
+		return nil, err
+	}
+
+	{
+		buf, dt, _, err := jsonparser.Get(buf, "cmd")
+		switch {
+		case err == nil:
+			switch dt {
+			case jsonparser.Array:
+				err = json.Unmarshal(buf, &action.Cmd)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("container.exec: cmd bad type")
+			}
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	if len(action.Cmd) == 0 {
+		return nil, fmt.Errorf("container.exec: no cmd given")
+	}
+
+	{
+		buf, dt, _, err := jsonparser.Get(buf, "env")
+		switch {
+		case err == nil:
+			switch dt {
+			case jsonparser.Array:
+				err = json.Unmarshal(buf, &action.Env)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("container.exec: env bad type")
+			}
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	return action, nil
+}
+
+func (a *actionContainerExec) Do(e Env, p params.Params) error {
+	container := e.Container()
+	if container == nil {
+		return fmt.Errorf("container.exec: no container")
+	}
+
+	cmd, err := interpolateAll(p, a.Cmd)
+	if err != nil {
+		return err
+	}
+
+	env, err := interpolateAll(p, a.Env)
+	if err != nil {
+		return err
+	}
+
+	stdout := logWriter(e.Log().Debugln)
+	stderr := logWriter(e.Log().Errorln)
+
+	code, err := container.Exec(e.Context(), cmd, env, stdout, stderr)
+	if err != nil {
+		return err
+	}
+
+	if code != 0 {
+		return fmt.Errorf("container.exec: %v exited with code %v", cmd[0], code)
+	}
+
+	return nil
+}
+
+func interpolateAll(p params.Params, texts []string) ([]string, error) {
+	var vals []string
+	for _, text := range texts {
+		val, err := p.Interpolate(text)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+// logWriter sends everything written to it to a log function.
+type logWriter func(args ...interface{})
+
+func (fn logWriter) Write(buf []byte) (int, error) {
+	fn(string(buf))
+	return len(buf), nil
+}
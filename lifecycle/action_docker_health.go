@@ -0,0 +1,92 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+)
+
+const (
+	actionDockerHealthDefaultTimeout  = time.Second * 30
+	actionDockerHealthDefaultInterval = time.Millisecond * 250
+)
+
+func init() {
+	MakeActionPlugin("docker.health", actionDockerHealthParse)
+}
+
+// actionDockerHealth waits for the HEALTHCHECK declared
+// by the image to report that the container is healthy.
+type actionDockerHealth struct {
+	ActionConfig
+
+	// how often to poll the health status
+	Interval time.Duration
+}
+
+func actionDockerHealthParse(buf []byte) (Action, error) {
+	ac := DefaultActionConfig()
+	ac.Timeout = actionDockerHealthDefaultTimeout
+
+	action := &actionDockerHealth{
+		ActionConfig: ac,
+		Interval:     actionDockerHealthDefaultInterval,
+	}
+
+	if err := json.Unmarshal(buf, action); err != nil {
+		return nil, err
+	}
+
+	{
+		val, err := jsonparser.GetString(buf, "interval")
+		switch {
+		case err == nil:
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return nil, parseError("interval", err)
+			}
+			action.Interval = d
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	return action, nil
+}
+
+func (a *actionDockerHealth) Do(e Env, p params.Params) error {
+	container := e.Container()
+	if container == nil {
+		return fmt.Errorf("docker.health: no container")
+	}
+
+	for {
+		health, err := container.Health(e.Context())
+		if err != nil {
+			return err
+		}
+
+		switch health.Status {
+		case HealthHealthy:
+			return nil
+		case HealthUnhealthy:
+			return Unrecoverable(fmt.Errorf("docker.health: container unhealthy: %v",
+				strings.TrimSpace(health.Output)))
+		case HealthNone:
+			return Unrecoverable(fmt.Errorf("docker.health: image has no HEALTHCHECK"))
+		}
+
+		e.Log().Debugf("health: %v", health.Status)
+
+		select {
+		case <-e.Context().Done():
+			return e.Context().Err()
+		case <-time.After(a.Interval):
+		}
+	}
+}
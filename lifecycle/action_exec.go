@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os/exec"
+	"strings"
 	"sync"
 	"time"
 
@@ -116,6 +117,10 @@ func (a *actionExec) Do(e Env, p params.Params) error {
 		fmt.Sprintf("EPHEMERALD_URL=%v", p.Url),
 	}
 
+	for name, port := range p.Ports {
+		env = append(env, fmt.Sprintf("%v=%v", portEnvName(name), port))
+	}
+
 	for _, text := range a.Env {
 		val, err := p.Interpolate(text)
 		if err != nil {
@@ -177,3 +182,16 @@ func copyLogs(r io.Reader, logfn func(args ...interface{})) {
 		}
 	}
 }
+
+// portEnvName returns the environment variable for a named port.
+// Characters that aren't allowed in variable names become '_'.
+func portEnvName(name string) string {
+	return "EPHEMERALD_PORT_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.ToUpper(name))
+}
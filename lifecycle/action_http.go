@@ -1,11 +1,17 @@
 package lifecycle
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strings"
 	"text/template"
 
 	"github.com/boz/ephemerald/params"
@@ -18,7 +24,11 @@ func init() {
 
 type actionHttpGet struct {
 	ActionConfig
-	Url  string
+	Url string
+
+	// named port to send the request to.  overrides the port of the url.
+	Port string
+
 	tmpl *template.Template
 }
 
@@ -42,6 +52,17 @@ func actionHttpGetParse(buf []byte) (Action, error) {
 		}
 	}
 
+	{
+		val, err := jsonparser.GetString(buf, "port")
+		switch {
+		case err == nil:
+			action.Port = val
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
 	if action.Url != "" {
 		tmpl, err := template.New("http-get-url").Parse(action.Url)
 		if err != nil {
@@ -71,6 +92,13 @@ func (a *actionHttpGet) Do(e Env, p params.Params) error {
 		return fmt.Errorf("http.get: no url found")
 	}
 
+	if a.Port != "" {
+		url, err = urlWithPort(url, p, a.Port)
+		if err != nil {
+			return err
+		}
+	}
+
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return err
@@ -90,3 +118,352 @@ func (a *actionHttpGet) Do(e Env, p params.Params) error {
 
 	return err
 }
+
+// urlWithPort replaces the host of url with the params
+// hostname and the named port.
+func urlWithPort(url string, p params.Params, name string) (string, error) {
+	port, err := p.PortFor(name)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return "", err
+	}
+
+	u.Host = net.JoinHostPort(p.Hostname, port)
+	return u.String(), nil
+}
+
+const (
+	// maximum number of response bytes read for assertions
+	actionHttpMaxBody = 1024 * 1024
+)
+
+func init() {
+	MakeActionPlugin("http", actionHttpParse)
+}
+
+// actionHttp sends an HTTP request and checks the response.
+type actionHttp struct {
+	ActionConfig
+
+	Method  string
+	Url     string
+	Port    string
+	Headers map[string]string
+	Body    string
+
+	// acceptable status codes.  any 2xx status if empty.
+	Status []int
+
+	// regular expression that the response body must match.
+	Match string
+
+	// value in a JSON response body that must match.
+	JSON *actionHttpJSON
+
+	TLS actionHttpTLS
+
+	urlTmpl     *template.Template
+	bodyTmpl    *template.Template
+	headerTmpls map[string]*template.Template
+	match       *regexp.Regexp
+}
+
+type actionHttpJSON struct {
+	// dot-separated path to the value; array elements are given as [n].
+	Path string
+
+	// acceptable values.
+	Value actionHttpValues
+}
+
+type actionHttpTLS struct {
+	// do not verify the server's certificate.
+	SkipVerify bool `json:"skip_verify"`
+
+	// PEM file of certificate authorities to verify the server with.
+	// relative to the configuration file.
+	CA string
+}
+
+// actionHttpValues is given either as a single value or a list
+// of values.  Numbers and booleans are compared as written.
+type actionHttpValues []string
+
+func (v *actionHttpValues) UnmarshalJSON(buf []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(buf, &raw); err != nil {
+		raw = []json.RawMessage{buf}
+	}
+
+	vals := make(actionHttpValues, 0, len(raw))
+	for _, item := range raw {
+		var val interface{}
+		if err := json.Unmarshal(item, &val); err != nil {
+			return err
+		}
+		switch val := val.(type) {
+		case string:
+			vals = append(vals, val)
+		case float64, bool:
+			vals = append(vals, strings.TrimSpace(string(item)))
+		default:
+			return fmt.Errorf("invalid value: %s", item)
+		}
+	}
+	*v = vals
+	return nil
+}
+
+func actionHttpParse(buf []byte) (Action, error) {
+	action := &actionHttp{
+		ActionConfig: DefaultActionConfig(),
+	}
+
+	if err := json.Unmarshal(buf, action); err != nil {
+		return nil, err
+	}
+
+	// ActionConfig.UnmarshalJSON is promoted; decode the
+	// remaining fields separately.
+	opts := struct {
+		Method  string
+		Url     string
+		Port    string
+		Headers map[string]string
+		Body    string
+		Status  []int
+		Match   string
+		JSON    *actionHttpJSON `json:"json"`
+		TLS     actionHttpTLS   `json:"tls"`
+	}{}
+
+	if err := json.Unmarshal(buf, &opts); err != nil {
+		return nil, err
+	}
+
+	action.Method = strings.ToUpper(opts.Method)
+	action.Url = opts.Url
+	action.Port = opts.Port
+	action.Headers = opts.Headers
+	action.Body = opts.Body
+	action.Status = opts.Status
+	action.Match = opts.Match
+	action.JSON = opts.JSON
+	action.TLS = opts.TLS
+
+	if action.Method == "" {
+		action.Method = "GET"
+	}
+
+	if action.JSON != nil && action.JSON.Path == "" {
+		return nil, fmt.Errorf("http: no json path given")
+	}
+
+	if err := action.compile(); err != nil {
+		return nil, err
+	}
+
+	return action, nil
+}
+
+func (a *actionHttp) compile() error {
+	if a.Url != "" {
+		tmpl, err := template.New("http-url").Parse(a.Url)
+		if err != nil {
+			return err
+		}
+		a.urlTmpl = tmpl
+	}
+
+	if a.Body != "" {
+		tmpl, err := template.New("http-body").Parse(a.Body)
+		if err != nil {
+			return err
+		}
+		a.bodyTmpl = tmpl
+	}
+
+	a.headerTmpls = make(map[string]*template.Template)
+	for k, v := range a.Headers {
+		tmpl, err := template.New("http-header").Parse(v)
+		if err != nil {
+			return err
+		}
+		a.headerTmpls[k] = tmpl
+	}
+
+	if a.Match != "" {
+		match, err := regexp.Compile(a.Match)
+		if err != nil {
+			return parseError("match", err)
+		}
+		a.match = match
+	}
+
+	return nil
+}
+
+// newClient creates a client with the configured TLS options.
+func (a *actionHttp) newClient(e Env) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: a.TLS.SkipVerify,
+	}
+
+	if a.TLS.CA != "" {
+		fpath := e.Path(a.TLS.CA)
+		pem, err := ioutil.ReadFile(fpath)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("http: no certificates found in %v", fpath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			TLSClientConfig:   tlsConfig,
+			DisableKeepAlives: true,
+		},
+	}, nil
+}
+
+func (a *actionHttp) Do(e Env, p params.Params) error {
+	req, err := a.request(p)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.newClient(e)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req.WithContext(e.Context()))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, actionHttpMaxBody))
+	if err != nil {
+		return err
+	}
+
+	return a.check(resp.StatusCode, body)
+}
+
+func (a *actionHttp) request(p params.Params) (*http.Request, error) {
+	url := p.Url
+
+	if a.urlTmpl != nil {
+		val, err := p.ExecuteTemplate(a.urlTmpl)
+		if err != nil {
+			return nil, err
+		}
+		url = val
+	}
+
+	if url == "" {
+		return nil, fmt.Errorf("http: no url found")
+	}
+
+	if a.Port != "" {
+		val, err := urlWithPort(url, p, a.Port)
+		if err != nil {
+			return nil, err
+		}
+		url = val
+	}
+
+	var body io.Reader
+	if a.bodyTmpl != nil {
+		val, err := p.ExecuteTemplate(a.bodyTmpl)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(val)
+	}
+
+	req, err := http.NewRequest(a.Method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, tmpl := range a.headerTmpls {
+		val, err := p.ExecuteTemplate(tmpl)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(k, "host") {
+			req.Host = val
+			continue
+		}
+		req.Header.Set(k, val)
+	}
+
+	return req, nil
+}
+
+func (a *actionHttp) check(status int, body []byte) error {
+	if !a.statusOK(status) {
+		return fmt.Errorf("http: unexpected status %v", status)
+	}
+
+	if a.match != nil && !a.match.Match(body) {
+		return fmt.Errorf("http: response does not match %v", a.match)
+	}
+
+	if a.JSON != nil {
+		return a.checkJSON(body)
+	}
+
+	return nil
+}
+
+func (a *actionHttp) statusOK(status int) bool {
+	if len(a.Status) == 0 {
+		return status >= 200 && status < 300
+	}
+	for _, val := range a.Status {
+		if val == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *actionHttp) checkJSON(body []byte) error {
+	path := strings.Split(a.JSON.Path, ".")
+
+	val, vt, _, err := jsonparser.Get(body, path...)
+	if err != nil {
+		return fmt.Errorf("http: %v: %v", a.JSON.Path, err)
+	}
+
+	if len(a.JSON.Value) == 0 {
+		return nil
+	}
+
+	str := string(val)
+	if vt == jsonparser.String {
+		if str, err = jsonparser.ParseString(val); err != nil {
+			return err
+		}
+	}
+
+	for _, expected := range a.JSON.Value {
+		if str == expected {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("http: %v is %v; expected %v", a.JSON.Path, str, strings.Join(a.JSON.Value, " or "))
+}
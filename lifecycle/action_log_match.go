@@ -0,0 +1,114 @@
+package lifecycle
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+)
+
+const (
+	actionLogMatchDefaultTimeout = time.Second * 30
+)
+
+func init() {
+	MakeActionPlugin("log.match", actionLogMatchParse)
+}
+
+// actionLogMatch waits for the container to output a line
+// matching a pattern.
+type actionLogMatch struct {
+	ActionConfig
+
+	Pattern *regexp.Regexp
+
+	// number of matching lines required
+	Count int
+}
+
+func actionLogMatchParse(buf []byte) (Action, error) {
+	ac := DefaultActionConfig()
+	ac.Timeout = actionLogMatchDefaultTimeout
+
+	action := &actionLogMatch{
+		ActionConfig: ac,
+		Count:        1,
+	}
+
+	if err := json.Unmarshal(buf, action); err != nil {
+		return nil, err
+	}
+
+	{
+		val, err := jsonparser.GetString(buf, "pattern")
+		switch {
+		case err == nil:
+			re, err := regexp.Compile(val)
+			if err != nil {
+				return nil, parseError("pattern", err)
+			}
+			action.Pattern = re
+		case err == jsonparser.KeyPathNotFoundError:
+			return nil, fmt.Errorf("log.match: no pattern given")
+		default:
+			return nil, err
+		}
+	}
+
+	{
+		val, err := jsonparser.GetInt(buf, "count")
+		switch {
+		case err == nil:
+			if val < 1 {
+				return nil, fmt.Errorf("log.match: invalid count %v", val)
+			}
+			action.Count = int(val)
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	return action, nil
+}
+
+func (a *actionLogMatch) Do(e Env, p params.Params) error {
+	container := e.Container()
+	if container == nil {
+		return fmt.Errorf("log.match: no container")
+	}
+
+	logs, err := container.Logs(e.Context())
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	matches := 0
+
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		if !a.Pattern.Match(scanner.Bytes()) {
+			continue
+		}
+		matches++
+		e.Log().Debugf("log.match: matched [%v/%v]", matches, a.Count)
+		if matches >= a.Count {
+			return nil
+		}
+	}
+
+	if err := e.Context().Err(); err != nil {
+		return err
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("log.match: '%v' matched %v of %v times", a.Pattern, matches, a.Count)
+}
@@ -5,6 +5,7 @@ import (
 	"net"
 
 	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
 )
 
 func init() {
@@ -13,18 +14,43 @@ func init() {
 
 type actionTCPConnect struct {
 	ActionConfig
+
+	// named port to connect to.  the primary port is used if empty.
+	Port string
 }
 
 func actionTCPConnectParse(buf []byte) (Action, error) {
 	action := &actionTCPConnect{
 		ActionConfig: DefaultActionConfig(),
 	}
-	return action, json.Unmarshal(buf, action)
+
+	if err := json.Unmarshal(buf, action); err != nil {
+		return nil, err
+	}
+
+	{
+		val, err := jsonparser.GetString(buf, "port")
+		switch {
+		case err == nil:
+			action.Port = val
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, err
+		}
+	}
+
+	return action, nil
 }
 
 func (a *actionTCPConnect) Do(e Env, p params.Params) error {
-	address := net.JoinHostPort(p.Hostname, p.Port)
+	port, err := p.PortFor(a.Port)
+	if err != nil {
+		return err
+	}
+	address := net.JoinHostPort(p.Hostname, port)
 	con, err := net.DialTimeout("tcp", address, a.Timeout)
-	defer con.Close()
-	return err
+	if err != nil {
+		return err
+	}
+	return con.Close()
 }
@@ -0,0 +1,195 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"text/template"
+
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+)
+
+const (
+	// maximum number of response bytes read
+	actionTCPExpectMaxRead = 64 * 1024
+)
+
+func init() {
+	MakeActionPlugin("tcp.expect", actionTCPExpectParse)
+}
+
+// actionTCPExpect connects to a port, optionally sends a payload,
+// and waits for a response that matches a regular expression or
+// starts with a prefix.
+type actionTCPExpect struct {
+	ActionConfig
+
+	// named port to connect to.  the primary port is used if empty.
+	Port string
+
+	// payload to send after connecting.
+	Send string
+
+	// regular expression that the response must match.
+	Match string
+
+	// bytes that the response must start with.
+	Prefix string
+
+	sendTmpl *template.Template
+	match    *regexp.Regexp
+}
+
+func actionTCPExpectParse(buf []byte) (Action, error) {
+	action := &actionTCPExpect{
+		ActionConfig: DefaultActionConfig(),
+	}
+
+	if err := json.Unmarshal(buf, action); err != nil {
+		return nil, err
+	}
+
+	for key, dst := range map[string]*string{
+		"port":   &action.Port,
+		"send":   &action.Send,
+		"match":  &action.Match,
+		"prefix": &action.Prefix,
+	} {
+		val, err := jsonparser.GetString(buf, key)
+		switch {
+		case err == nil:
+			*dst = val
+		case err == jsonparser.KeyPathNotFoundError:
+		default:
+			return nil, parseError(key, err)
+		}
+	}
+
+	if action.Match == "" && action.Prefix == "" {
+		return nil, fmt.Errorf("tcp.expect: match or prefix required")
+	}
+
+	if action.Send != "" {
+		tmpl, err := template.New("tcp-expect-send").Parse(action.Send)
+		if err != nil {
+			return nil, parseError("send", err)
+		}
+		action.sendTmpl = tmpl
+	}
+
+	if action.Match != "" {
+		match, err := regexp.Compile(action.Match)
+		if err != nil {
+			return nil, parseError("match", err)
+		}
+		action.match = match
+	}
+
+	return action, nil
+}
+
+func (a *actionTCPExpect) Do(e Env, p params.Params) error {
+	port, err := p.PortFor(a.Port)
+	if err != nil {
+		return err
+	}
+
+	var payload string
+	if a.sendTmpl != nil {
+		if payload, err = p.ExecuteTemplate(a.sendTmpl); err != nil {
+			return err
+		}
+	}
+
+	address := net.JoinHostPort(p.Hostname, port)
+
+	dialer := &net.Dialer{}
+	con, err := dialer.DialContext(e.Context(), "tcp", address)
+	if err != nil {
+		return err
+	}
+	defer con.Close()
+
+	// unblock reads and writes when the attempt is cancelled.
+	donech := make(chan struct{})
+	defer close(donech)
+	go func() {
+		select {
+		case <-e.Context().Done():
+			con.Close()
+		case <-donech:
+		}
+	}()
+
+	if payload != "" {
+		if _, err := io.WriteString(con, payload); err != nil {
+			return err
+		}
+	}
+
+	return a.expect(e.Context(), con)
+}
+
+// expect reads from r until the response matches, can no longer
+// match, the connection is closed, or ctx is done.
+func (a *actionTCPExpect) expect(ctx context.Context, r io.Reader) error {
+	var response []byte
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := r.Read(buf)
+		response = append(response, buf[:n]...)
+
+		if ok, done := a.check(response); done {
+			if ok {
+				return nil
+			}
+			return fmt.Errorf("tcp.expect: unexpected response: %q", response)
+		}
+
+		if len(response) >= actionTCPExpectMaxRead {
+			return fmt.Errorf("tcp.expect: no match in %v bytes", len(response))
+		}
+
+		if err == io.EOF {
+			return fmt.Errorf("tcp.expect: connection closed: %q", response)
+		}
+		if err != nil && ctx.Err() != nil {
+			// the read was interrupted by cancellation; report
+			// what was received rather than the closed connection.
+			return fmt.Errorf("tcp.expect: unexpected response: %q", response)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// check returns whether the response matches and whether
+// reading more of the response could change the result.
+func (a *actionTCPExpect) check(response []byte) (bool, bool) {
+	if a.Prefix != "" {
+		prefix := []byte(a.Prefix)
+		if len(response) < len(prefix) {
+			if !bytes.HasPrefix(prefix, response) {
+				return false, true
+			}
+			return false, false
+		}
+		if !bytes.HasPrefix(response, prefix) {
+			return false, true
+		}
+		if a.match == nil {
+			return true, true
+		}
+	}
+	if a.match.Match(response) {
+		return true, true
+	}
+	return false, false
+}
@@ -1,13 +1,26 @@
 package lifecycle_test
 
 import (
+	"archive/tar"
+	"bufio"
 	"context"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/boz/ephemerald/lifecycle"
 	"github.com/boz/ephemerald/params"
 	"github.com/boz/ephemerald/testutil"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -38,16 +51,140 @@ func TestParseAction_defaults(t *testing.T) {
 	}
 }
 
+func TestParseAction_backoff(t *testing.T) {
+	actions := map[string]lifecycle.Action{
+		"json": actionFromFile(t, "base.backoff.json"),
+		"yaml": actionFromFile(t, "base.backoff.yaml"),
+	}
+
+	for ext, action := range actions {
+		cfg := action.Config()
+		require.Equal(t, lifecycle.BackoffExponential, cfg.Backoff.Strategy, ext)
+		require.Equal(t, 5*time.Second, cfg.Backoff.Max, ext)
+		require.Equal(t, 0.2, cfg.Backoff.Jitter, ext)
+
+		// 5 * 1s timeout + (1s + 2s + 4s + 5s + 5s) * 1.2
+		assert.Equal(t, 5*time.Second+20400*time.Millisecond, cfg.MaxDelay(), ext)
+
+		for attempt, base := range []time.Duration{1, 2, 4, 5, 5, 5} {
+			base *= time.Second
+			delay := cfg.RetryDelay(attempt + 1)
+			assert.True(t, delay >= base*8/10 && delay <= base*12/10, "%v: attempt %v: %v", ext, attempt+1, delay)
+		}
+	}
+
+	_, err := lifecycle.ParseAction([]byte(`{"type": "noop", "backoff": {"strategy": "linear"}}`))
+	assert.Error(t, err)
+
+	_, err = lifecycle.ParseAction([]byte(`{"type": "noop", "backoff": {"jitter": 2}}`))
+	assert.Error(t, err)
+}
+
+func TestActionConfig_constant(t *testing.T) {
+	cfg := lifecycle.DefaultActionConfig()
+	assert.Equal(t, lifecycle.ActionDefaultDelay, cfg.RetryDelay(1))
+	assert.Equal(t, lifecycle.ActionDefaultDelay, cfg.RetryDelay(3))
+	assert.Equal(t,
+		(lifecycle.ActionDefaultDelay+lifecycle.ActionDefaultTimeout)*(lifecycle.ActionDefaultRetries+1),
+		cfg.MaxDelay())
+}
+
 func TestActionExec(t *testing.T) {
 	runActionFromFile(t, "action.exec.json", "exec", params.Params{}, true, "exec")
 	runActionFromFile(t, "action.exec.yaml", "exec", params.Params{}, true, "exec")
 }
 
+func TestActionExec_ports(t *testing.T) {
+	action, err := lifecycle.ParseAction([]byte(`{"type":"exec","path":"sh",
+		"args":["-c","test \"$EPHEMERALD_PORT_ADMIN_API\" = 8081 && test \"$EPHEMERALD_PORT_DB\" = 5432"]}`))
+	require.NoError(t, err)
+
+	p := params.Params{Ports: map[string]string{"admin-api": "8081", "db": "5432"}}
+	assert.NoError(t, action.Do(actionEnv(t), p))
+}
+
 func TestActionHttpPing(t *testing.T) {
 	runActionFromFile(t, "action.http.get.json", "http.get", params.Params{}, true, "http.get")
 	runActionFromFile(t, "action.http.get.yaml", "http.get", params.Params{}, true, "http.get")
 }
 
+func TestActionHttp(t *testing.T) {
+	status := "red"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != "PUT" ||
+			r.URL.Path != "/_cluster/health" ||
+			r.Header.Get("Content-Type") != "application/json" ||
+			r.Header.Get("X-Container") != "127.0.0.1" ||
+			!strings.Contains(string(body), `"port"`) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"status": %q, "number_of_nodes": 1}`, status)
+	}))
+	defer server.Close()
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	p := params.Params{Hostname: host, Port: port}
+
+	runActionFromFile(t, "action.http.json", "http", p, false, "http: red")
+
+	status = "yellow"
+	runActionFromFile(t, "action.http.json", "http", p, true, "http: yellow")
+	runActionFromFile(t, "action.http.yaml", "http", p, true, "http: yellow")
+}
+
+func TestActionHttp_status(t *testing.T) {
+	code := http.StatusInternalServerError
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+		fmt.Fprint(w, `{"nodes": [{"up": true}]}`)
+	}))
+	defer server.Close()
+
+	action, err := lifecycle.ParseAction([]byte(`{"type": "http", "json": {"path": "nodes.[0].up", "value": true}}`))
+	require.NoError(t, err)
+
+	p := params.Params{Url: server.URL}
+
+	assert.Error(t, action.Do(actionEnv(t), p))
+
+	code = http.StatusOK
+	assert.NoError(t, action.Do(actionEnv(t), p))
+}
+
+func TestActionHttp_tls(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	p := params.Params{Url: server.URL}
+
+	action, err := lifecycle.ParseAction([]byte(`{"type": "http"}`))
+	require.NoError(t, err)
+	assert.Error(t, action.Do(actionEnv(t), p))
+
+	action, err = lifecycle.ParseAction([]byte(`{"type": "http", "tls": {"skip_verify": true}}`))
+	require.NoError(t, err)
+	assert.NoError(t, action.Do(actionEnv(t), p))
+
+	// ca is read when run, relative to the configuration file.
+	dir, err := ioutil.TempDir("", "ephemerald-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	action, err = lifecycle.ParseAction([]byte(`{"type": "http", "tls": {"ca": "ca.pem"}}`))
+	require.NoError(t, err)
+	assert.Error(t, action.Do(dirEnv{actionEnv(t), dir}, p))
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.pem"), ca, 0644))
+	assert.NoError(t, action.Do(dirEnv{actionEnv(t), dir}, p))
+}
+
 func TestActionTCPConnect(t *testing.T) {
 	runActionFromFile(t, "action.tcp.connect.json", "tcp.connect", params.Params{Hostname: "google.com", Port: "80"}, true, "tcp.connect")
 	runActionFromFile(t, "action.tcp.connect.yaml", "tcp.connect", params.Params{Hostname: "google.com", Port: "80"}, true, "tcp.connect")
@@ -79,3 +216,304 @@ func actionEnv(t *testing.T) lifecycle.Env {
 	log := testutil.Log()
 	return lifecycle.NewEnv(context.Background(), log.WithField("test", t.Name()))
 }
+
+func TestActionTCPConnect_namedPort(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+
+	action, err := lifecycle.ParseAction([]byte(`{"type":"tcp.connect","port":"admin"}`))
+	require.NoError(t, err)
+
+	p := params.Params{Hostname: "127.0.0.1", Ports: map[string]string{"admin": port}}
+	require.NoError(t, action.Do(actionEnv(t), p))
+
+	p.Ports = nil
+	require.Error(t, action.Do(actionEnv(t), p))
+}
+
+func TestActionTCPExpect(t *testing.T) {
+	l := tcpServer(t, func(con net.Conn) {
+		line, err := bufio.NewReader(con).ReadString('\n')
+		if err != nil {
+			return
+		}
+		if line == "ruok 127.0.0.1\n" {
+			fmt.Fprint(con, "imok")
+		} else {
+			fmt.Fprint(con, "unknown command")
+		}
+	})
+	defer l.Close()
+
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+
+	p := params.Params{Hostname: "127.0.0.1", Ports: map[string]string{"admin": port}}
+	runActionFromFile(t, "action.tcp.expect.json", "tcp.expect", p, true, "tcp.expect")
+	runActionFromFile(t, "action.tcp.expect.yaml", "tcp.expect", p, true, "tcp.expect")
+
+	p.Hostname = "localhost"
+	runActionFromFile(t, "action.tcp.expect.json", "tcp.expect", p, false, "tcp.expect: unknown command")
+}
+
+func TestActionTCPExpect_prefix(t *testing.T) {
+	l := tcpServer(t, func(con net.Conn) {
+		fmt.Fprint(con, "220 ")
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(con, "smtp.example.com ESMTP\r\n")
+	})
+	defer l.Close()
+
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	p := params.Params{Hostname: "127.0.0.1", Port: port}
+
+	action, err := lifecycle.ParseAction([]byte(`{"type": "tcp.expect", "prefix": "220 smtp"}`))
+	require.NoError(t, err)
+	assert.NoError(t, action.Do(actionEnv(t), p))
+
+	action, err = lifecycle.ParseAction([]byte(`{"type": "tcp.expect", "prefix": "554"}`))
+	require.NoError(t, err)
+	assert.Error(t, action.Do(actionEnv(t), p))
+
+	_, err = lifecycle.ParseAction([]byte(`{"type": "tcp.expect", "send": "stats"}`))
+	assert.Error(t, err)
+}
+
+func TestActionTCPExpect_matchTimeout(t *testing.T) {
+	l := tcpServer(t, func(con net.Conn) {
+		fmt.Fprint(con, "busy")
+		ioutil.ReadAll(con)
+	})
+	defer l.Close()
+
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	p := params.Params{Hostname: "127.0.0.1", Port: port}
+
+	action, err := lifecycle.ParseAction([]byte(`{"type": "tcp.expect", "match": "^ok"}`))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = action.Do(lifecycle.NewEnv(ctx, testutil.Log()), p)
+	require.Error(t, err)
+	assert.Equal(t, `tcp.expect: unexpected response: "busy"`, err.Error())
+}
+
+func TestActionTCPConnect_closed(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	l.Close()
+
+	action, err := lifecycle.ParseAction([]byte(`{"type":"tcp.connect"}`))
+	require.NoError(t, err)
+	assert.Error(t, action.Do(actionEnv(t), params.Params{Hostname: "127.0.0.1", Port: port}))
+}
+
+// tcpServer serves each connection with fn.
+func tcpServer(t *testing.T, fn func(net.Conn)) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			con, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer con.Close()
+				fn(con)
+			}()
+		}
+	}()
+
+	return l
+}
+
+func TestActionContainerExec(t *testing.T) {
+	p := params.Params{Config: params.Config{Username: "postgres", Database: "test"}}
+
+	for _, name := range []string{"action.container.exec.json", "action.container.exec.yaml"} {
+		action := actionFromFile(t, name)
+		require.Equal(t, "container.exec", action.Config().Type, name)
+
+		c := &fakeContainer{}
+		env := lifecycle.NewContainerEnv(context.Background(), testutil.Log(), c)
+		require.NoError(t, action.Do(env, p), name)
+		require.Equal(t, []string{"psql", "-U", "postgres", "-c", "SELECT 1"}, c.cmd, name)
+		require.Equal(t, []string{"PGDATABASE=test"}, c.env, name)
+
+		c.code = 1
+		require.Error(t, action.Do(env, p), name)
+
+		require.Error(t, action.Do(actionEnv(t), p), name)
+	}
+}
+
+func TestActionContainerCopy(t *testing.T) {
+	p := params.Params{Config: params.Config{Database: "test"}}
+
+	for _, name := range []string{"action.container.copy.json", "action.container.copy.yaml"} {
+		action := actionFromFile(t, name)
+		require.Equal(t, "container.copy", action.Config().Type, name)
+
+		c := &fakeContainer{}
+		env := lifecycle.NewContainerEnv(context.Background(), testutil.Log(), c)
+		require.NoError(t, action.Do(env, p), name)
+
+		require.Equal(t, "/docker-entrypoint-initdb.d", c.dest, name)
+		require.Equal(t, map[string]string{
+			"conf/":           "",
+			"conf/extra.conf": "listen_addresses = *\n",
+			"init.sql":        "CREATE DATABASE test;\n",
+		}, c.files, name)
+	}
+
+	// relative to the configuration file.
+	action, err := lifecycle.ParseAction([]byte(`{"type":"container.copy","src":"copy/init.sql","dest":"/tmp","template":true}`))
+	require.NoError(t, err)
+
+	c := &fakeContainer{}
+	env := dirEnv{lifecycle.NewContainerEnv(context.Background(), testutil.Log(), c), "_testdata"}
+	require.NoError(t, action.Do(env, p))
+	require.Equal(t, map[string]string{"init.sql": "CREATE DATABASE test;\n"}, c.files)
+
+	require.Error(t, action.Do(lifecycle.NewContainerEnv(context.Background(), testutil.Log(), c), p))
+}
+
+// dirEnv resolves relative paths against dir.
+type dirEnv struct {
+	lifecycle.Env
+	dir string
+}
+
+func (e dirEnv) Path(name string) string {
+	return filepath.Join(e.dir, name)
+}
+
+func TestActionDockerHealth(t *testing.T) {
+	starting := lifecycle.Health{Status: lifecycle.HealthStarting}
+	healthy := lifecycle.Health{Status: lifecycle.HealthHealthy}
+	unhealthy := lifecycle.Health{Status: lifecycle.HealthUnhealthy, Output: "connection refused"}
+	none := lifecycle.Health{Status: lifecycle.HealthNone}
+
+	for _, name := range []string{"action.docker.health.json", "action.docker.health.yaml"} {
+		action := actionFromFile(t, name)
+		require.Equal(t, "docker.health", action.Config().Type, name)
+
+		for _, c := range []struct {
+			health []lifecycle.Health
+			ok     bool
+		}{
+			{[]lifecycle.Health{starting, starting, healthy}, true},
+			{[]lifecycle.Health{starting, unhealthy}, false},
+			{[]lifecycle.Health{none}, false},
+		} {
+			container := &fakeContainer{health: c.health}
+			env := lifecycle.NewContainerEnv(context.Background(), testutil.Log(), container)
+			err := action.Do(env, params.Params{})
+			if c.ok {
+				require.NoError(t, err, name)
+			} else {
+				require.Error(t, err, name)
+			}
+		}
+	}
+}
+
+func TestActionDockerHealth_unrecoverable(t *testing.T) {
+	m := lifecycle.NewManager(testutil.Log())
+	require.NoError(t, m.ParseConfig([]byte(`{"healthcheck":{"type":"docker.health","retries":3,"delay":"1ms"}}`)))
+
+	container := &fakeContainer{health: []lifecycle.Health{{Status: lifecycle.HealthUnhealthy}}}
+	cm := m.ForContainer(testutil.ContainerEmitter(), container)
+
+	require.Error(t, cm.DoHealthcheck(context.Background(), params.Params{}))
+	require.Equal(t, 1, container.healthCalls)
+}
+
+func TestActionLogMatch(t *testing.T) {
+	once := "LOG:  database system is ready to accept connections\nLOG:  database system is shut down\n"
+	twice := once + "LOG:  database system is ready to accept connections\n"
+
+	for _, name := range []string{"action.log.match.json", "action.log.match.yaml"} {
+		action := actionFromFile(t, name)
+		require.Equal(t, "log.match", action.Config().Type, name)
+
+		env := lifecycle.NewContainerEnv(context.Background(), testutil.Log(), &fakeContainer{logs: twice})
+		require.NoError(t, action.Do(env, params.Params{}), name)
+
+		env = lifecycle.NewContainerEnv(context.Background(), testutil.Log(), &fakeContainer{logs: once})
+		require.Error(t, action.Do(env, params.Params{}), name)
+	}
+}
+
+// fakeContainer records exec and copy requests.
+type fakeContainer struct {
+	cmd  []string
+	env  []string
+	code int
+
+	dest  string
+	files map[string]string
+
+	// health states returned in order; the last is repeated.
+	health      []lifecycle.Health
+	healthCalls int
+
+	logs string
+}
+
+func (c *fakeContainer) ID() string {
+	return testutil.CID()
+}
+
+func (c *fakeContainer) Exec(ctx context.Context, cmd []string, env []string, stdout, stderr io.Writer) (int, error) {
+	c.cmd = cmd
+	c.env = env
+	fmt.Fprintln(stdout, "output")
+	return c.code, nil
+}
+
+func (c *fakeContainer) Health(ctx context.Context) (lifecycle.Health, error) {
+	c.healthCalls++
+	health := c.health[0]
+	if len(c.health) > 1 {
+		c.health = c.health[1:]
+	}
+	return health, nil
+}
+
+func (c *fakeContainer) Logs(ctx context.Context) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(c.logs)), nil
+}
+
+func (c *fakeContainer) CopyTo(ctx context.Context, dest string, content io.Reader) error {
+	c.dest = dest
+	c.files = make(map[string]string)
+
+	tr := tar.NewReader(content)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		buf, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		c.files[header.Name] = string(buf)
+	}
+}
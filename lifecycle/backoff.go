@@ -0,0 +1,101 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	BackoffConstant    = "constant"
+	BackoffExponential = "exponential"
+)
+
+// Backoff determines how long to wait between attempts of an action.
+//
+// With the constant strategy every retry waits Delay.  With the exponential
+// strategy the delay doubles after each attempt, up to Max.  Jitter randomizes
+// each delay by up to the given fraction in either direction so that
+// containers started together don't retry in lockstep.
+type Backoff struct {
+	Strategy string
+	Max      time.Duration
+	Jitter   float64
+}
+
+func (b *Backoff) UnmarshalJSON(buf []byte) error {
+	other := struct {
+		Strategy string
+		Max      string
+		Jitter   float64
+	}{Jitter: b.Jitter}
+
+	if err := json.Unmarshal(buf, &other); err != nil {
+		return err
+	}
+
+	switch other.Strategy {
+	case "", BackoffConstant, BackoffExponential:
+		b.Strategy = other.Strategy
+	default:
+		return parseError("strategy", fmt.Errorf("unknown strategy '%v'", other.Strategy))
+	}
+
+	if other.Max != "" {
+		val, err := time.ParseDuration(other.Max)
+		if err != nil {
+			return parseError("max", err)
+		}
+		b.Max = val
+	}
+
+	if other.Jitter < 0 || other.Jitter > 1 {
+		return parseError("jitter", fmt.Errorf("must be between 0 and 1"))
+	}
+	b.Jitter = other.Jitter
+
+	return nil
+}
+
+// RetryDelay returns the amount of time to wait after the
+// given attempt (starting at 1) fails.
+func (ac ActionConfig) RetryDelay(attempt int) time.Duration {
+	delay := ac.baseDelay(attempt)
+	if ac.Backoff.Jitter == 0 || delay == 0 {
+		return delay
+	}
+	factor := 1 + ac.Backoff.Jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(delay) * factor)
+}
+
+// MaxDelay returns the longest the action can take,
+// including every retry and the delays between them.
+func (ac ActionConfig) MaxDelay() time.Duration {
+	total := time.Duration(0)
+	for attempt := 1; attempt <= ac.Retries+1; attempt++ {
+		delay := ac.baseDelay(attempt)
+		delay += time.Duration(float64(delay) * ac.Backoff.Jitter)
+		total += ac.Timeout + delay
+	}
+	return total
+}
+
+// baseDelay is the delay after the given attempt without jitter.
+func (ac ActionConfig) baseDelay(attempt int) time.Duration {
+	if ac.Backoff.Strategy != BackoffExponential {
+		return ac.Delay
+	}
+
+	delay := ac.Delay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if ac.Backoff.Max > 0 && delay >= ac.Backoff.Max {
+			return ac.Backoff.Max
+		}
+	}
+	if ac.Backoff.Max > 0 && delay > ac.Backoff.Max {
+		return ac.Backoff.Max
+	}
+	return delay
+}
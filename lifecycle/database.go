@@ -0,0 +1,64 @@
+package lifecycle
+
+import (
+	"fmt"
+
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+)
+
+var (
+	databasePlugins = map[string]DatabaseProviderPlugin{}
+)
+
+// DatabaseProvider creates and drops the databases that a shared
+// pool hands out.  Each checkout receives its own database inside
+// one of the pool's containers.
+type DatabaseProvider interface {
+	// Create creates the database name on the server described by
+	// server and returns the params for connecting to it.
+	Create(e Env, server params.Params, name string) (params.Params, error)
+
+	// Drop removes a database, and anything else created along
+	// with it, that was returned by Create.
+	Drop(e Env, server params.Params, db params.Params) error
+}
+
+type DatabaseProviderPlugin interface {
+	Name() string
+	ParseConfig([]byte) (DatabaseProvider, error)
+}
+
+func ParseDatabaseProvider(buf []byte) (DatabaseProvider, error) {
+	t, err := jsonparser.GetString(buf, "type")
+	if err != nil {
+		return nil, parseError("type", err)
+	}
+
+	dp, ok := databasePlugins[t]
+	if !ok {
+		return nil, fmt.Errorf("database provider '%v' not found", t)
+	}
+	return dp.ParseConfig(buf)
+}
+
+func MakeDatabaseProviderPlugin(name string, fn func(buf []byte) (DatabaseProvider, error)) {
+	RegisterDatabaseProviderPlugin(&databasePlugin{name, fn})
+}
+
+func RegisterDatabaseProviderPlugin(dp DatabaseProviderPlugin) {
+	databasePlugins[dp.Name()] = dp
+}
+
+type databasePlugin struct {
+	name        string
+	parseConfig func([]byte) (DatabaseProvider, error)
+}
+
+func (d *databasePlugin) Name() string {
+	return d.name
+}
+
+func (d *databasePlugin) ParseConfig(buf []byte) (DatabaseProvider, error) {
+	return d.parseConfig(buf)
+}
@@ -2,6 +2,8 @@ package lifecycle
 
 import (
 	"context"
+	"io"
+	"path/filepath"
 
 	"github.com/Sirupsen/logrus"
 )
@@ -9,15 +11,73 @@ import (
 type Env interface {
 	Context() context.Context
 	Log() logrus.FieldLogger
+
+	// Container returns the container that the action is run
+	// against.  It is nil if there is no container.
+	Container() Container
+
+	// Step reports progress of an action made up of several
+	// steps, such as the file currently being applied.
+	Step(name string)
+
+	// Path resolves a file name given in the configuration.
+	// Relative names are relative to the directory containing
+	// the configuration file.
+	Path(name string) string
+}
+
+// Container provides access to the docker container that
+// actions are run against.
+type Container interface {
+	ID() string
+
+	// Exec runs cmd inside the container and copies its output to stdout
+	// and stderr.  The exit code of the command is returned.
+	Exec(ctx context.Context, cmd []string, env []string, stdout, stderr io.Writer) (int, error)
+
+	// CopyTo extracts the tar archive content into the directory
+	// dest inside the container.
+	CopyTo(ctx context.Context, dest string, content io.Reader) error
+
+	// Health returns the state of the image's HEALTHCHECK.
+	Health(ctx context.Context) (Health, error)
+
+	// Logs returns a reader of the container's output from the start.
+	// The reader blocks for new output until the container exits or
+	// ctx is done.
+	Logs(ctx context.Context) (io.ReadCloser, error)
+}
+
+// Docker HEALTHCHECK states
+const (
+	HealthNone      = "none"
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// Health is the state of a container's docker HEALTHCHECK.
+type Health struct {
+	Status string
+
+	// output of the most recent check
+	Output string
 }
 
 type env struct {
-	ctx context.Context
-	log logrus.FieldLogger
+	ctx       context.Context
+	log       logrus.FieldLogger
+	container Container
+	step      func(string)
+	dir       string
 }
 
 func NewEnv(ctx context.Context, log logrus.FieldLogger) Env {
-	return &env{ctx, log}
+	return &env{ctx: ctx, log: log}
+}
+
+func NewContainerEnv(ctx context.Context, log logrus.FieldLogger, container Container) Env {
+	return &env{ctx: ctx, log: log, container: container}
 }
 
 func (e *env) Context() context.Context {
@@ -27,3 +87,21 @@ func (e *env) Context() context.Context {
 func (e *env) Log() logrus.FieldLogger {
 	return e.log
 }
+
+func (e *env) Container() Container {
+	return e.container
+}
+
+func (e *env) Step(name string) {
+	e.log.WithField("step", name).Debug("step")
+	if e.step != nil {
+		e.step(name)
+	}
+}
+
+func (e *env) Path(name string) string {
+	if e.dir == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(e.dir, name)
+}
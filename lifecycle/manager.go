@@ -18,7 +18,8 @@ var (
 type Manager interface {
 	ParseConfig([]byte) error
 	MaxDelay() time.Duration
-	ForContainer(ui.ContainerEmitter, string) ContainerManager
+	StartDelay() time.Duration
+	ForContainer(ui.ContainerEmitter, Container) ContainerManager
 }
 
 type ContainerManager interface {
@@ -30,60 +31,107 @@ type ContainerManager interface {
 
 	HasReset() bool
 	DoReset(context.Context, params.Params) error
+
+	// run before an item is handed to a client.
+	HasOnCheckout() bool
+	DoOnCheckout(context.Context, params.Params) error
+
+	// run after an item is returned, before it is reset.
+	HasOnReturn() bool
+	DoOnReturn(context.Context, params.Params) error
+
+	// run before the container is killed.
+	HasPreStop() bool
+	DoPreStop(context.Context, params.Params) error
 }
 
 type manager struct {
-	initializeAction  Action
-	healthcheckAction Action
-	resetAction       Action
+	initialize  step
+	healthcheck step
+	reset       step
+	onCheckout  step
+	onReturn    step
+	preStop     step
+
+	// directory that relative file names are resolved against.
+	dir string
 
 	log logrus.FieldLogger
 }
 
 type containerManager struct {
 	manager
-	ui ui.ContainerEmitter
+	ui        ui.ContainerEmitter
+	container Container
 }
 
 func NewManager(log logrus.FieldLogger) Manager {
-	return &manager{log: log.WithField("component", "lifecycle.Manager")}
+	return NewManagerInDir(log, "")
+}
+
+// NewManagerInDir returns a Manager whose actions resolve relative
+// file names against dir.
+func NewManagerInDir(log logrus.FieldLogger, dir string) Manager {
+	return &manager{dir: dir, log: log.WithField("component", "lifecycle.Manager")}
 }
 
-func (m *manager) ForContainer(uie ui.ContainerEmitter, id string) ContainerManager {
+func (m *manager) ForContainer(uie ui.ContainerEmitter, container Container) ContainerManager {
 	next := &containerManager{
-		manager: *m,
-		ui:      uie,
+		manager:   *m,
+		ui:        uie,
+		container: container,
 	}
-	next.log = m.log.WithField("container", id[0:12])
+	next.log = m.log.WithField("container", container.ID()[0:12])
 	return next
 }
 
 func (m *manager) ParseConfig(buf []byte) error {
 	{
-		action, err := m.parseAction(buf, "initialize")
+		step, err := m.parseStep(buf, "initialize")
 		if err != nil {
 			return parseError("initialize", err)
 		}
-		m.initializeAction = action
+		m.initialize = step
 	}
 	{
-		action, err := m.parseAction(buf, "healthcheck")
+		step, err := m.parseStep(buf, "healthcheck")
 		if err != nil {
 			return parseError("healthcheck", err)
 		}
-		m.healthcheckAction = action
+		m.healthcheck = step
 	}
 	{
-		action, err := m.parseAction(buf, "reset")
+		step, err := m.parseStep(buf, "reset")
 		if err != nil {
 			return parseError("reset", err)
 		}
-		m.resetAction = action
+		m.reset = step
+	}
+	{
+		step, err := m.parseStep(buf, "on-checkout")
+		if err != nil {
+			return parseError("on-checkout", err)
+		}
+		m.onCheckout = step
+	}
+	{
+		step, err := m.parseStep(buf, "on-return")
+		if err != nil {
+			return parseError("on-return", err)
+		}
+		m.onReturn = step
+	}
+	{
+		step, err := m.parseStep(buf, "pre-stop")
+		if err != nil {
+			return parseError("pre-stop", err)
+		}
+		m.preStop = step
 	}
 	return nil
 }
 
-func (m *manager) parseAction(buf []byte, key string) (Action, error) {
+func (m *manager) parseStep(buf []byte, key string) (step, error) {
 	vbuf, vt, _, err := jsonparser.Get(buf, key)
 	if vt == jsonparser.NotExist && err == jsonparser.KeyPathNotFoundError {
 		return nil, nil
@@ -92,72 +140,118 @@ func (m *manager) parseAction(buf []byte, key string) (Action, error) {
 		return nil, err
 	}
 	switch vt {
-	case jsonparser.Object:
-		return ParseAction(vbuf)
+	case jsonparser.Object, jsonparser.Array:
+		return parseStep(vbuf, vt)
 	default:
 		return nil, fmt.Errorf("lifecycle manager: invalid config at %v: ", key)
 	}
 }
 
+// MaxDelay returns the longest that a ready item can take to become
+// ready again.  Hooks are not included.
 func (m *manager) MaxDelay() time.Duration {
 	max := time.Duration(0)
-	for _, action := range m.actions() {
-		cfg := action.Config()
-		val := (cfg.Delay + cfg.Timeout) * time.Duration(cfg.Retries+1)
-		if val > max {
+	for _, step := range m.readySteps() {
+		if val := step.maxDelay(); val > max {
 			max = val
 		}
 	}
 	return max
 }
 
-func (m *manager) actions() []Action {
-	var actions []Action
-	if m.initializeAction != nil {
-		actions = append(actions, m.initializeAction)
+// StartDelay returns the longest that a new container can take
+// to pass its healthcheck and initialize actions.
+func (m *manager) StartDelay() time.Duration {
+	delay := time.Duration(0)
+	if m.healthcheck != nil {
+		delay += m.healthcheck.maxDelay()
+	}
+	if m.initialize != nil {
+		delay += m.initialize.maxDelay()
+	}
+	return delay
+}
+
+func (m *manager) readySteps() []step {
+	var steps []step
+	if m.initialize != nil {
+		steps = append(steps, m.initialize)
 	}
-	if m.healthcheckAction != nil {
-		actions = append(actions, m.healthcheckAction)
+	if m.healthcheck != nil {
+		steps = append(steps, m.healthcheck)
 	}
-	if m.resetAction != nil {
-		actions = append(actions, m.resetAction)
+	if m.reset != nil {
+		steps = append(steps, m.reset)
 	}
-	return actions
+	return steps
 }
 
 func (m *containerManager) HasInitialize() bool {
-	return m.initializeAction != nil
+	return m.initialize != nil
 }
 
 func (m *containerManager) DoInitialize(ctx context.Context, p params.Params) error {
 	if !m.HasInitialize() {
 		return ErrActionNotConfigured
 	}
-	return m.runAction(ctx, m.initializeAction, p, "initialize")
+	return m.runStep(ctx, m.initialize, p, "initialize")
 }
 
 func (m *containerManager) HasHealthcheck() bool {
-	return m.healthcheckAction != nil
+	return m.healthcheck != nil
 }
 
 func (m *containerManager) DoHealthcheck(ctx context.Context, p params.Params) error {
 	if !m.HasHealthcheck() {
 		return ErrActionNotConfigured
 	}
-	return m.runAction(ctx, m.healthcheckAction, p, "healthcheck")
+	return m.runStep(ctx, m.healthcheck, p, "healthcheck")
 }
 
 func (m *containerManager) HasReset() bool {
-	return m.resetAction != nil
+	return m.reset != nil
 }
 
 func (m *containerManager) DoReset(ctx context.Context, p params.Params) error {
 	if !m.HasReset() {
 		return ErrActionNotConfigured
 	}
-	return m.runAction(ctx, m.resetAction, p, "reset")
+	return m.runStep(ctx, m.reset, p, "reset")
+}
+
+func (m *containerManager) HasOnCheckout() bool {
+	return m.onCheckout != nil
+}
+
+func (m *containerManager) DoOnCheckout(ctx context.Context, p params.Params) error {
+	if !m.HasOnCheckout() {
+		return ErrActionNotConfigured
+	}
+	return m.runStep(ctx, m.onCheckout, p, "on-checkout")
+}
+
+func (m *containerManager) HasOnReturn() bool {
+	return m.onReturn != nil
+}
+
+func (m *containerManager) DoOnReturn(ctx context.Context, p params.Params) error {
+	if !m.HasOnReturn() {
+		return ErrActionNotConfigured
+	}
+	return m.runStep(ctx, m.onReturn, p, "on-return")
+}
+
+func (m *containerManager) HasPreStop() bool {
+	return m.preStop != nil
+}
+
+func (m *containerManager) DoPreStop(ctx context.Context, p params.Params) error {
+	if !m.HasPreStop() {
+		return ErrActionNotConfigured
+	}
+	return m.runStep(ctx, m.preStop, p, "pre-stop")
 }
 
-func (m *containerManager) runAction(ctx context.Context, action Action, p params.Params, name string) error {
-	return newActionRunner(ctx, m.ui, m.log, action, p, name).Run()
+func (m *containerManager) runStep(ctx context.Context, step step, p params.Params, name string) error {
+	return step.run(ctx, m, stepContext{name, p})
 }
@@ -1,9 +1,16 @@
 package lifecycle_test
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
 	"github.com/boz/ephemerald/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,7 +23,7 @@ func TestParseManager_full(t *testing.T) {
 	}
 
 	for ext, m := range ms {
-		cm := m.ForContainer(testutil.ContainerEmitter(), testutil.CID())
+		cm := m.ForContainer(testutil.ContainerEmitter(), testutil.Container())
 
 		assert.True(t, cm.HasInitialize(), ext)
 		assert.True(t, cm.HasHealthcheck(), ext)
@@ -31,13 +38,208 @@ func TestParseManager_partial(t *testing.T) {
 	}
 
 	for ext, m := range ms {
-		cm := m.ForContainer(testutil.ContainerEmitter(), testutil.CID())
+		cm := m.ForContainer(testutil.ContainerEmitter(), testutil.Container())
 		assert.True(t, cm.HasInitialize(), ext)
 		assert.False(t, cm.HasHealthcheck(), ext)
 		assert.False(t, cm.HasReset(), ext)
 	}
 }
 
+func TestParseManager_hooks(t *testing.T) {
+	ms := map[string]lifecycle.Manager{
+		"json": managerFromFile(t, "manager.hooks.json"),
+		"yaml": managerFromFile(t, "manager.hooks.yaml"),
+	}
+
+	for ext, m := range ms {
+		cm := m.ForContainer(testutil.ContainerEmitter(), testutil.Container())
+		assert.True(t, cm.HasHealthcheck(), ext)
+		assert.True(t, cm.HasOnCheckout(), ext)
+		assert.True(t, cm.HasOnReturn(), ext)
+		assert.True(t, cm.HasPreStop(), ext)
+
+		assert.NoError(t, cm.DoOnCheckout(context.Background(), params.Params{}), ext)
+		assert.NoError(t, cm.DoOnReturn(context.Background(), params.Params{}), ext)
+		assert.NoError(t, cm.DoPreStop(context.Background(), params.Params{}), ext)
+
+		// hooks don't count towards the readiness delay.
+		assert.Equal(t, 500*time.Millisecond, m.MaxDelay(), ext)
+	}
+
+	cm := managerFromFile(t, "manager.partial.json").
+		ForContainer(testutil.ContainerEmitter(), testutil.Container())
+	assert.False(t, cm.HasOnCheckout())
+	assert.False(t, cm.HasOnReturn())
+	assert.False(t, cm.HasPreStop())
+	assert.Equal(t, lifecycle.ErrActionNotConfigured, cm.DoPreStop(context.Background(), params.Params{}))
+}
+
+func TestParseManager_composite(t *testing.T) {
+	ms := map[string]lifecycle.Manager{
+		"json": managerFromFile(t, "manager.composite.json"),
+		"yaml": managerFromFile(t, "manager.composite.yaml"),
+	}
+
+	for ext, m := range ms {
+		cm := m.ForContainer(testutil.ContainerEmitter(), testutil.Container())
+		assert.True(t, cm.HasInitialize(), ext)
+		assert.False(t, cm.HasHealthcheck(), ext)
+		assert.False(t, cm.HasReset(), ext)
+
+		// 1s + max(3s, 1s + 1s)
+		assert.Equal(t, 4*time.Second, m.MaxDelay(), ext)
+		assert.Equal(t, 4*time.Second, m.StartDelay(), ext)
+	}
+}
+
+func TestParseManager_invalid(t *testing.T) {
+	for _, buf := range []string{
+		`{"initialize": []}`,
+		`{"initialize": {"type": "parallel"}}`,
+		`{"initialize": {"type": "parallel", "actions": {"type": "noop"}}}`,
+		`{"initialize": [{"type": "noop"}, "noop"]}`,
+	} {
+		m := lifecycle.NewManager(testutil.Log())
+		assert.Error(t, m.ParseConfig([]byte(buf)), buf)
+	}
+}
+
+func TestManager_sequence(t *testing.T) {
+	buf := `{"initialize": [
+		{"type": "test.record", "name": "a"},
+		{"type": "test.record", "name": "b", "fail": true},
+		{"type": "test.record", "name": "c"}
+	]}`
+
+	calls := runRecorded(t, buf)
+	assert.Equal(t, []string{"a", "b"}, calls)
+}
+
+func TestManager_parallel(t *testing.T) {
+	buf := `{"initialize": [
+		{"type": "test.record", "name": "a"},
+		{"type": "parallel", "actions": [
+			{"type": "test.record", "name": "b"},
+			[
+				{"type": "test.record", "name": "c"},
+				{"type": "test.record", "name": "d"}
+			]
+		]}
+	]}`
+
+	calls := runRecorded(t, buf)
+	sort.Strings(calls)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, calls)
+}
+
+func TestManager_dir(t *testing.T) {
+	buf := `{"initialize": [
+		{"type": "test.record", "path": "fixtures/a.sql"},
+		{"type": "test.record", "path": "/abs/b.sql"}
+	]}`
+
+	calls := runRecordedInDir(t, "/etc/ephemerald", buf)
+	assert.Equal(t, []string{"/etc/ephemerald/fixtures/a.sql", "/abs/b.sql"}, calls)
+
+	calls = runRecorded(t, buf)
+	assert.Equal(t, []string{"fixtures/a.sql", "/abs/b.sql"}, calls)
+}
+
+func runRecorded(t *testing.T, buf string) []string {
+	return runRecordedInDir(t, "", buf)
+}
+
+func runRecordedInDir(t *testing.T, dir string, buf string) []string {
+	recorder.reset()
+
+	m := lifecycle.NewManagerInDir(testutil.Log(), dir)
+	require.NoError(t, m.ParseConfig([]byte(buf)))
+
+	cm := m.ForContainer(testutil.ContainerEmitter(), testutil.Container())
+	err := cm.DoInitialize(context.Background(), params.Params{})
+
+	calls, failed := recorder.get()
+	if failed {
+		assert.Error(t, err)
+	} else {
+		assert.NoError(t, err)
+	}
+	return calls
+}
+
+// test.record records each invocation and fails if "fail" is set.
+// If "path" is set, the resolved path is recorded instead of the name.
+var recorder = &callRecorder{}
+
+func init() {
+	lifecycle.MakeActionPlugin("test.record", func(buf []byte) (lifecycle.Action, error) {
+		action := &recordAction{ActionConfig: lifecycle.DefaultActionConfig()}
+		action.Retries = 0
+		action.Delay = 0
+		if err := json.Unmarshal(buf, action); err != nil {
+			return nil, err
+		}
+
+		var opts struct {
+			Name string
+			Path string
+			Fail bool
+		}
+		if err := json.Unmarshal(buf, &opts); err != nil {
+			return nil, err
+		}
+		action.name = opts.Name
+		action.path = opts.Path
+		action.fail = opts.Fail
+		return action, nil
+	})
+}
+
+type recordAction struct {
+	lifecycle.ActionConfig
+	name string
+	path string
+	fail bool
+}
+
+func (a *recordAction) Do(e lifecycle.Env, _ params.Params) error {
+	if a.path != "" {
+		recorder.add(e.Path(a.path), a.fail)
+	} else {
+		recorder.add(a.name, a.fail)
+	}
+	if a.fail {
+		return fmt.Errorf("%v failed", a.name)
+	}
+	return nil
+}
+
+type callRecorder struct {
+	calls  []string
+	failed bool
+	mtx    sync.Mutex
+}
+
+func (r *callRecorder) reset() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.calls = nil
+	r.failed = false
+}
+
+func (r *callRecorder) add(name string, fail bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.calls = append(r.calls, name)
+	r.failed = r.failed || fail
+}
+
+func (r *callRecorder) get() ([]string, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]string(nil), r.calls...), r.failed
+}
+
 func managerFromFile(t *testing.T, fpath string) lifecycle.Manager {
 	buf := testutil.ReadJSON(t, fpath)
 	log := testutil.Log()
@@ -14,17 +14,35 @@ var (
 	ErrRetryCountExceeded = fmt.Errorf("retry count exceeded")
 )
 
+// unrecoverableError is returned by actions that cannot succeed if retried.
+type unrecoverableError struct {
+	error
+}
+
+// Unrecoverable marks err as an error that retrying will not fix.
+// The action fails immediately instead of being retried.
+func Unrecoverable(err error) error {
+	return unrecoverableError{err}
+}
+
+func isUnrecoverable(err error) bool {
+	_, ok := err.(unrecoverableError)
+	return ok
+}
+
 type actionRunner struct {
 	action     Action
 	actionType string
 	actionName string
 	p          params.Params
+	container  Container
+	dir        string
 	ctx        context.Context
 	log        logrus.FieldLogger
 	uie        ui.ContainerEmitter
 }
 
-func newActionRunner(ctx context.Context, uie ui.ContainerEmitter, log logrus.FieldLogger, action Action, p params.Params, actionName string) *actionRunner {
+func newActionRunner(ctx context.Context, uie ui.ContainerEmitter, log logrus.FieldLogger, container Container, dir string, action Action, p params.Params, actionName string) *actionRunner {
 
 	actionType := action.Config().Type
 
@@ -36,6 +54,8 @@ func newActionRunner(ctx context.Context, uie ui.ContainerEmitter, log logrus.Fi
 		actionType: actionType,
 		actionName: actionName,
 		p:          p,
+		container:  container,
+		dir:        dir,
 		ctx:        ctx,
 		log:        log,
 		uie:        uie,
@@ -45,16 +65,16 @@ func newActionRunner(ctx context.Context, uie ui.ContainerEmitter, log logrus.Fi
 func (ar *actionRunner) Run() error {
 
 	attempt := 1
-	retries := ar.action.Config().Retries
-	timeout := ar.action.Config().Timeout
-	delay := ar.action.Config().Delay
+	config := ar.action.Config()
+	retries := config.Retries
+	timeout := config.Timeout
 
 	maxAttempts := retries + 1
 
 	for {
 
 		if ar.ctx.Err() != nil {
-			ar.uie.EmitActionResult(ar.actionName, ar.actionType, attempt, maxAttempts, ar.ctx.Err())
+			ar.uie.EmitActionResult(ar.actionName, ar.actionType, attempt, maxAttempts, 0, ar.ctx.Err())
 			return ar.ctx.Err()
 		}
 
@@ -62,7 +82,15 @@ func (ar *actionRunner) Run() error {
 
 		err, ok := ar.doAttempt(attempt, timeout)
 
-		ar.uie.EmitActionResult(ar.actionName, ar.actionType, attempt, maxAttempts, err)
+		retry := ok && err != nil && !isUnrecoverable(err) && attempt <= retries
+
+		// delay before the next attempt, if any.
+		var delay time.Duration
+		if retry {
+			delay = config.RetryDelay(attempt)
+		}
+
+		ar.uie.EmitActionResult(ar.actionName, ar.actionType, attempt, maxAttempts, delay, err)
 
 		if !ok {
 			return err
@@ -72,11 +100,18 @@ func (ar *actionRunner) Run() error {
 			return nil
 		}
 
-		if attempt > retries {
+		if isUnrecoverable(err) {
+			ar.log.WithError(err).Warn("unrecoverable error")
+			return err
+		}
+
+		if !retry {
 			ar.log.WithError(err).Warn("retry count exceeded")
 			return ErrRetryCountExceeded
 		}
 
+		ar.log.WithError(err).Debugf("retrying in %v", delay)
+
 		attempt++
 
 		select {
@@ -94,7 +129,15 @@ func (ar *actionRunner) doAttempt(attempt int, timeout time.Duration) (error, bo
 	ctx, cancel := context.WithTimeout(ar.ctx, timeout)
 	defer cancel()
 
-	env := NewEnv(ctx, ar.log.WithField("attempt", attempt))
+	env := &env{
+		ctx:       ctx,
+		log:       ar.log.WithField("attempt", attempt),
+		container: ar.container,
+		dir:       ar.dir,
+		step: func(name string) {
+			ar.uie.EmitActionStep(ar.actionName, ar.actionType, name)
+		},
+	}
 
 	go func() {
 		err := ar.action.Do(env, ar.p)
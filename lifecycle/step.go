@@ -0,0 +1,177 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/boz/ephemerald/params"
+	"github.com/buger/jsonparser"
+)
+
+// step is a unit of work in a lifecycle: a single action,
+// a sequence of steps, or a group of steps run in parallel.
+type step interface {
+	run(ctx context.Context, m *containerManager, sc stepContext) error
+
+	// upper bound on how long the step can take.
+	maxDelay() time.Duration
+}
+
+// stepContext is the lifecycle being run.
+type stepContext struct {
+	name string
+	p    params.Params
+}
+
+type actionStep struct {
+	action Action
+}
+
+type sequenceStep struct {
+	steps []step
+}
+
+type parallelStep struct {
+	steps []step
+}
+
+// parseStep parses a lifecycle entry.  Arrays are run in
+// sequence; objects with type "parallel" run the steps in
+// "actions" concurrently; other objects are actions.
+func parseStep(buf []byte, vt jsonparser.ValueType) (step, error) {
+	switch vt {
+	case jsonparser.Array:
+		steps, err := parseSteps(buf)
+		if err != nil {
+			return nil, err
+		}
+		return &sequenceStep{steps}, nil
+
+	case jsonparser.Object:
+		t, err := jsonparser.GetString(buf, "type")
+		if err != nil {
+			return nil, parseError("type", err)
+		}
+
+		if t != "parallel" {
+			action, err := ParseAction(buf)
+			if err != nil {
+				return nil, err
+			}
+			return &actionStep{action}, nil
+		}
+
+		abuf, avt, _, err := jsonparser.Get(buf, "actions")
+		if err != nil {
+			return nil, parseError("actions", err)
+		}
+		if avt != jsonparser.Array {
+			return nil, fmt.Errorf("parallel: actions must be a list")
+		}
+
+		steps, err := parseSteps(abuf)
+		if err != nil {
+			return nil, err
+		}
+		return &parallelStep{steps}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid step type: %v", vt)
+	}
+}
+
+func parseSteps(buf []byte) ([]step, error) {
+	var steps []step
+	var perr error
+
+	_, err := jsonparser.ArrayEach(buf, func(vbuf []byte, vt jsonparser.ValueType, _ int, err error) {
+		if perr != nil {
+			return
+		}
+		if err != nil {
+			perr = err
+			return
+		}
+		step, err := parseStep(vbuf, vt)
+		if err != nil {
+			perr = err
+			return
+		}
+		steps = append(steps, step)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	if perr != nil {
+		return nil, perr
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("empty list of actions")
+	}
+	return steps, nil
+}
+
+func (s *actionStep) run(ctx context.Context, m *containerManager, sc stepContext) error {
+	return newActionRunner(ctx, m.ui, m.log, m.container, m.dir, s.action, sc.p, sc.name).Run()
+}
+
+func (s *actionStep) maxDelay() time.Duration {
+	return s.action.Config().MaxDelay()
+}
+
+func (s *sequenceStep) run(ctx context.Context, m *containerManager, sc stepContext) error {
+	for _, step := range s.steps {
+		if err := step.run(ctx, m, sc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sequenceStep) maxDelay() time.Duration {
+	total := time.Duration(0)
+	for _, step := range s.steps {
+		total += step.maxDelay()
+	}
+	return total
+}
+
+// run runs every step concurrently.  The remaining steps
+// are cancelled when any step fails.
+func (s *parallelStep) run(ctx context.Context, m *containerManager, sc stepContext) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var result error
+
+	for _, child := range s.steps {
+		wg.Add(1)
+		go func(child step) {
+			defer wg.Done()
+			if err := child.run(ctx, m, sc); err != nil {
+				once.Do(func() {
+					result = err
+					cancel()
+				})
+			}
+		}(child)
+	}
+
+	wg.Wait()
+	return result
+}
+
+func (s *parallelStep) maxDelay() time.Duration {
+	max := time.Duration(0)
+	for _, step := range s.steps {
+		if val := step.maxDelay(); val > max {
+			max = val
+		}
+	}
+	return max
+}
@@ -0,0 +1,187 @@
+package ephemerald
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const (
+	// number of bytes of output kept for each container
+	containerLogSize = 64 * 1024
+
+	// number of lines of output included in lifecycle errors
+	containerLogTailLines = 20
+)
+
+// logBuffer keeps the most recent output of a container.
+type logBuffer struct {
+	max int
+
+	data []byte
+
+	// number of bytes discarded from the front of data
+	offset int64
+
+	// closed and replaced on every write
+	changed chan struct{}
+
+	closed bool
+
+	mtx sync.Mutex
+}
+
+func newLogBuffer(max int) *logBuffer {
+	return &logBuffer{
+		max:     max,
+		changed: make(chan struct{}),
+	}
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if b.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	b.data = append(b.data, p...)
+	if over := len(b.data) - b.max; over > 0 {
+		b.data = append([]byte(nil), b.data[over:]...)
+		b.offset += int64(over)
+	}
+
+	close(b.changed)
+	b.changed = make(chan struct{})
+
+	return len(p), nil
+}
+
+// Close marks the end of the output.  Readers that are
+// following the output return io.EOF once they catch up.
+func (b *logBuffer) Close() error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if !b.closed {
+		b.closed = true
+		close(b.changed)
+	}
+	return nil
+}
+
+// tail returns the last n lines of output.
+func (b *logBuffer) tail(n int) string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	lines := strings.Split(strings.TrimRight(string(b.data), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// reader returns a reader over the buffered output.  If follow is
+// true, the reader blocks for new output until the buffer or
+// the reader is closed.
+func (b *logBuffer) reader(follow bool) io.ReadCloser {
+	return &logReader{
+		buf:    b,
+		follow: follow,
+		donech: make(chan struct{}),
+	}
+}
+
+// readAt copies buffered output starting at off into p.  off is
+// advanced past discarded output.  it returns the number of bytes
+// copied, the offset of the next byte, a channel that is closed
+// when there is new output, and whether the buffer is closed.
+func (b *logBuffer) readAt(p []byte, off int64) (int, int64, <-chan struct{}, bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if off < b.offset {
+		off = b.offset
+	}
+
+	n := copy(p, b.data[off-b.offset:])
+	return n, off + int64(n), b.changed, b.closed
+}
+
+type logReader struct {
+	buf    *logBuffer
+	off    int64
+	follow bool
+	donech chan struct{}
+	once   sync.Once
+}
+
+func (r *logReader) Read(p []byte) (int, error) {
+	for {
+		n, off, changed, closed := r.buf.readAt(p, r.off)
+		r.off = off
+
+		if n > 0 {
+			return n, nil
+		}
+
+		if !r.follow || closed {
+			return 0, io.EOF
+		}
+
+		select {
+		case <-changed:
+		case <-r.donech:
+			return 0, io.EOF
+		}
+	}
+}
+
+func (r *logReader) Close() error {
+	r.once.Do(func() { close(r.donech) })
+	return nil
+}
+
+// demuxLogs copies the multiplexed stdout/stderr stream returned
+// by the docker logs API for containers without a tty into w.
+func demuxLogs(w io.Writer, r io.Reader) error {
+	return demuxStreams(w, w, r)
+}
+
+// demuxStreams splits a multiplexed docker stream.  Each frame has an
+// 8-byte header: the stream type followed by the big-endian frame size.
+func demuxStreams(stdout, stderr io.Writer, r io.Reader) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		w := stdout
+		if header[0] == 2 {
+			w = stderr
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+	}
+}
+
+// errorWithLogs appends the tail of a container's output to err.
+func errorWithLogs(err error, logs *logBuffer) error {
+	tail := logs.tail(containerLogTailLines)
+	if strings.TrimSpace(tail) == "" {
+		return err
+	}
+	return fmt.Errorf("%v\ncontainer logs:\n%v", err, tail)
+}
@@ -0,0 +1,87 @@
+package ephemerald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogBuffer_bounded(t *testing.T) {
+	b := newLogBuffer(8)
+
+	fmt.Fprint(b, "abcdef")
+	fmt.Fprint(b, "ghijkl")
+
+	buf, err := ioutil.ReadAll(b.reader(false))
+	require.NoError(t, err)
+	assert.Equal(t, "efghijkl", string(buf))
+}
+
+func TestLogBuffer_tail(t *testing.T) {
+	b := newLogBuffer(1024)
+
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(b, "line %v\n", i)
+	}
+
+	assert.Equal(t, "line 3\nline 4", b.tail(2))
+	assert.Equal(t, "line 0\nline 1\nline 2\nline 3\nline 4", b.tail(10))
+}
+
+func TestLogBuffer_follow(t *testing.T) {
+	b := newLogBuffer(1024)
+	fmt.Fprint(b, "a")
+
+	r := b.reader(true)
+	ch := make(chan string)
+	go func() {
+		buf, _ := ioutil.ReadAll(r)
+		ch <- string(buf)
+	}()
+
+	fmt.Fprint(b, "b")
+	b.Close()
+
+	select {
+	case buf := <-ch:
+		assert.Equal(t, "ab", buf)
+	case <-time.After(time.Second):
+		t.Fatal("reader not closed")
+	}
+
+	// readers can be closed while waiting for output.
+	r = newLogBuffer(1024).reader(true)
+	go func() {
+		buf, _ := ioutil.ReadAll(r)
+		ch <- string(buf)
+	}()
+	r.Close()
+
+	select {
+	case buf := <-ch:
+		assert.Equal(t, "", buf)
+	case <-time.After(time.Second):
+		t.Fatal("reader not closed")
+	}
+}
+
+func TestDemuxLogs(t *testing.T) {
+	in := new(bytes.Buffer)
+	for stream, msg := range []string{"stdout\n", "stderr\n"} {
+		header := make([]byte, 8)
+		header[0] = byte(stream + 1)
+		binary.BigEndian.PutUint32(header[4:], uint32(len(msg)))
+		in.Write(header)
+		in.WriteString(msg)
+	}
+
+	out := new(bytes.Buffer)
+	require.NoError(t, demuxLogs(out, in))
+	assert.Equal(t, "stdout\nstderr\n", out.String())
+}
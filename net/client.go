@@ -4,25 +4,33 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/boz/ephemerald"
 	"github.com/boz/ephemerald/params"
+	"github.com/boz/ephemerald/ui"
 )
 
 type ClientBuilder struct {
 	address string
+	holder  string
+	timeout time.Duration
 }
 
 type Client struct {
 	address string
+	holder  string
+	timeout time.Duration
 }
 
 func NewClientBuilder() *ClientBuilder {
-	return &ClientBuilder{DefaultConnectAddress}
+	return &ClientBuilder{address: DefaultConnectAddress}
 }
 
 func (b *ClientBuilder) WithAddress(address string) *ClientBuilder {
@@ -36,14 +44,27 @@ func (b *ClientBuilder) WithPort(port int) *ClientBuilder {
 	return b
 }
 
+// WithHolder sets the name that checkouts are leased to.
+func (b *ClientBuilder) WithHolder(holder string) *ClientBuilder {
+	b.holder = holder
+	return b
+}
+
+// WithCheckoutTimeout limits how long the server waits for items
+// to become available when checking out.
+func (b *ClientBuilder) WithCheckoutTimeout(timeout time.Duration) *ClientBuilder {
+	b.timeout = timeout
+	return b
+}
+
 func (b *ClientBuilder) Create() (*Client, error) {
-	return &Client{b.address}, nil
+	return &Client{b.address, b.holder, b.timeout}, nil
 }
 
 func (c *Client) CheckoutBatch(names ...string) (params.Set, error) {
 	ps := params.Set{}
 
-	req, err := http.NewRequest("POST", c.url(rpcCheckoutPath), &bytes.Buffer{})
+	req, err := http.NewRequest("POST", c.checkoutURL(), &bytes.Buffer{})
 	if err != nil {
 		return ps, err
 	}
@@ -56,6 +77,10 @@ func (c *Client) CheckoutBatch(names ...string) (params.Set, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return ps, responseError(resp)
+	}
+
 	dec := json.NewDecoder(resp.Body)
 	err = dec.Decode(&ps)
 	return ps, err
@@ -64,7 +89,7 @@ func (c *Client) CheckoutBatch(names ...string) (params.Set, error) {
 func (c *Client) Checkout(name string) (params.Params, error) {
 	params := params.Params{}
 
-	req, err := http.NewRequest("POST", c.url(rpcCheckoutPath, name), &bytes.Buffer{})
+	req, err := http.NewRequest("POST", c.checkoutURL(name), &bytes.Buffer{})
 	if err != nil {
 		return params, err
 	}
@@ -77,6 +102,10 @@ func (c *Client) Checkout(name string) (params.Params, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return params, responseError(resp)
+	}
+
 	dec := json.NewDecoder(resp.Body)
 	err = dec.Decode(&params)
 	return params, err
@@ -99,6 +128,10 @@ func (c *Client) ReturnBatch(ps params.Set) error {
 		return err
 	}
 	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
 	return nil
 }
 
@@ -118,12 +151,122 @@ func (c *Client) Return(name string, item ephemerald.Item) error {
 		return err
 	}
 	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
 	return nil
 }
 
+// Heartbeat extends the lease of a checked-out item.
+func (c *Client) Heartbeat(name string, item ephemerald.Item) (ephemerald.Lease, error) {
+	lease := ephemerald.Lease{}
+
+	url := c.url(rpcHeartbeatPath, name, item.ID())
+
+	req, err := http.NewRequest("POST", url, &bytes.Buffer{})
+	if err != nil {
+		return lease, err
+	}
+	req.Header.Add("Content-Type", rpcContentType)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return lease, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return lease, responseError(resp)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	err = dec.Decode(&lease)
+	return lease, err
+}
+
+// Logs returns the output of an item.  If follow is true, the
+// output is streamed until the item exits or the reader is closed.
+func (c *Client) Logs(name string, item ephemerald.Item, follow bool) (io.ReadCloser, error) {
+	url := c.url(rpcPoolsPath, name, "items", item.ID(), "logs") +
+		"?follow=" + strconv.FormatBool(follow)
+
+	client := &http.Client{}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+
+	return resp.Body, nil
+}
+
+// Pools returns the status of every pool.
+func (c *Client) Pools() ([]ui.PoolStatus, error) {
+	var status []ui.PoolStatus
+	err := c.getJSON(c.url(rpcPoolsPath), &status)
+	return status, err
+}
+
+// Pool returns the status of the named pool.
+func (c *Client) Pool(name string) (ui.PoolStatus, error) {
+	var status ui.PoolStatus
+	err := c.getJSON(c.url(rpcPoolsPath, name), &status)
+	return status, err
+}
+
+// PoolItems returns the status of every item in the named pool.
+func (c *Client) PoolItems(name string) ([]ui.ItemStatus, error) {
+	var status []ui.ItemStatus
+	err := c.getJSON(c.url(rpcPoolsPath, name)+"/items", &status)
+	return status, err
+}
+
+func (c *Client) getJSON(url string, obj interface{}) error {
+	client := &http.Client{}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	return dec.Decode(obj)
+}
+
+func (c *Client) checkoutURL(parts ...string) string {
+	query := url.Values{}
+	if c.holder != "" {
+		query.Set("holder", c.holder)
+	}
+	if c.timeout > 0 {
+		query.Set("timeout", c.timeout.String())
+	}
+
+	u := c.url(rpcCheckoutPath, parts...)
+	if len(query) > 0 {
+		u = u + "?" + query.Encode()
+	}
+	return u
+}
+
 func (c *Client) url(path string, parts ...string) string {
 	for _, part := range parts {
 		path = path + "/" + url.QueryEscape(part)
 	}
 	return fmt.Sprintf("http://%v%v", c.address, path)
 }
+
+func responseError(resp *http.Response) error {
+	buf, _ := ioutil.ReadAll(resp.Body)
+	return fmt.Errorf("%v: %s", resp.Status, bytes.TrimSpace(buf))
+}
@@ -5,8 +5,10 @@ const (
 	DefaultListenAddress  = ":6000"
 	DefaultConnectAddress = "localhost:6000"
 
-	rpcCheckoutPath = "/checkout"
-	rpcReturnPath   = "/return"
+	rpcCheckoutPath  = "/checkout"
+	rpcReturnPath    = "/return"
+	rpcHeartbeatPath = "/heartbeat"
+	rpcPoolsPath     = "/pools"
 
 	rpcContentType = "application/json"
 )
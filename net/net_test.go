@@ -2,6 +2,7 @@ package net_test
 
 import (
 	"context"
+	"io/ioutil"
 	"testing"
 
 	"github.com/Sirupsen/logrus"
@@ -9,7 +10,7 @@ import (
 
 	_ "github.com/boz/ephemerald/builtin/postgres"
 	_ "github.com/boz/ephemerald/builtin/redis"
-	"github.com/boz/ephemerald/testutil"
+	"github.com/boz/ephemerald/ui"
 
 	"github.com/boz/ephemerald/config"
 	"github.com/boz/ephemerald/net"
@@ -23,7 +24,10 @@ func TestClientServer(t *testing.T) {
 	log := logrus.New()
 	log.Level = logrus.DebugLevel
 
-	uie := testutil.Emitter()
+	appui := ui.NewNoopUI()
+	defer appui.Stop()
+
+	uie := appui.Emitter()
 
 	ctx := context.Background()
 
@@ -36,6 +40,7 @@ func TestClientServer(t *testing.T) {
 	server, err := net.NewServerBuilder().
 		WithPort(0).
 		WithPoolSet(pools).
+		WithStatus(appui.Status()).
 		Create()
 	if err != nil {
 		pools.Stop()
@@ -72,8 +77,45 @@ func TestClientServer(t *testing.T) {
 		require.NoError(t, err)
 		defer func() {
 			require.NoError(t, client.Return("redis", rparam))
+			require.Error(t, client.Return("redis", rparam))
 		}()
 		doTestOperation(t, rparam, "single")
+
+		logs, err := client.Logs("redis", rparam, false)
+		require.NoError(t, err)
+		_, err = ioutil.ReadAll(logs)
+		require.NoError(t, err)
+		logs.Close()
+
+		_, err = client.Logs("redis", params.Params{Id: "unknown"}, false)
+		require.Error(t, err)
+
+		lease, err := client.Heartbeat("redis", rparam)
+		require.NoError(t, err)
+		require.Equal(t, rparam.ID(), lease.ID)
+
+		_, err = client.Heartbeat("redis", params.Params{Id: "unknown"})
+		require.Error(t, err)
+		require.Error(t, client.Return("redis", params.Params{Id: "unknown"}))
+	}()
+
+	func() {
+		pstatus, err := client.Pools()
+		require.NoError(t, err)
+		require.Len(t, pstatus, 1)
+		require.Equal(t, "redis", pstatus[0].Name)
+
+		status, err := client.Pool("redis")
+		require.NoError(t, err)
+		require.Equal(t, "redis", status.Name)
+
+		_, err = client.PoolItems("redis")
+		require.NoError(t, err)
+
+		_, err = client.Pool("unknown")
+		require.Error(t, err)
+		_, err = client.PoolItems("unknown")
+		require.Error(t, err)
 	}()
 }
 
@@ -3,12 +3,15 @@ package net
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/boz/ephemerald"
 	"github.com/boz/ephemerald/params"
+	"github.com/boz/ephemerald/ui"
 	"github.com/gorilla/mux"
 )
 
@@ -18,12 +21,15 @@ type Server struct {
 
 	pools ephemerald.PoolSet
 
+	status ui.Status
+
 	closech chan bool
 }
 
 type ServerBuilder struct {
 	address string
 	pools   ephemerald.PoolSet
+	status  ui.Status
 }
 
 func NewServerBuilder() *ServerBuilder {
@@ -37,6 +43,12 @@ func (sb *ServerBuilder) WithPoolSet(pools ephemerald.PoolSet) *ServerBuilder {
 	return sb
 }
 
+// WithStatus enables the status API.
+func (sb *ServerBuilder) WithStatus(status ui.Status) *ServerBuilder {
+	sb.status = status
+	return sb
+}
+
 func (sb *ServerBuilder) WithAddress(address string) *ServerBuilder {
 	sb.address = address
 	return sb
@@ -52,6 +64,7 @@ func (sb *ServerBuilder) Create() (*Server, error) {
 	server := &Server{
 		closech: make(chan bool),
 		pools:   sb.pools,
+		status:  sb.status,
 	}
 
 	r := mux.NewRouter()
@@ -67,6 +80,18 @@ func (sb *ServerBuilder) Create() (*Server, error) {
 	r.HandleFunc(rpcReturnPath+"/{pool}/{id}", server.handleReturn).
 		Methods("DELETE")
 
+	r.HandleFunc(rpcHeartbeatPath+"/{pool}/{id}", server.handleHeartbeat).
+		Methods("POST")
+
+	r.HandleFunc(rpcPoolsPath, server.handlePools).
+		Methods("GET")
+	r.HandleFunc(rpcPoolsPath+"/{pool}", server.handlePool).
+		Methods("GET")
+	r.HandleFunc(rpcPoolsPath+"/{pool}/items", server.handlePoolItems).
+		Methods("GET")
+	r.HandleFunc(rpcPoolsPath+"/{pool}/items/{id}/logs", server.handleItemLogs).
+		Methods("GET")
+
 	l, err := net.Listen("tcp", sb.address)
 	if err != nil {
 		return nil, err
@@ -116,7 +141,13 @@ func (s *Server) handleCheckoutBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ps, err := s.pools.CheckoutWith(r.Context())
+	opts, err := checkoutOptions(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	ps, err := s.pools.CheckoutWithOptions(r.Context(), nil, opts...)
 
 	for name, p := range ps {
 		p2, e := p.ForHost(host)
@@ -157,7 +188,13 @@ func (s *Server) handleCheckoutPool(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ps, err := s.pools.CheckoutWith(r.Context(), poolName)
+	opts, err := checkoutOptions(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	ps, err := s.pools.CheckoutWithOptions(r.Context(), []string{poolName}, opts...)
 	if err != nil {
 		s.pools.ReturnAll(ps)
 		http.Error(w, fmt.Sprint(err), http.StatusRequestTimeout)
@@ -197,7 +234,10 @@ func (s *Server) handleReturnBatch(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
 		return
 	}
-	s.pools.ReturnAll(ps)
+	if err := s.pools.ReturnAll(ps); err != nil {
+		http.Error(w, fmt.Sprint(err), poolErrorStatus(err))
+		return
+	}
 
 	w.Header().Set("Content-Type", rpcContentType)
 	w.WriteHeader(http.StatusOK)
@@ -216,12 +256,177 @@ func (s *Server) handleReturn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.pools.Return(pool, itemID(id))
+	if err := s.pools.Return(pool, itemID(id)); err != nil {
+		http.Error(w, fmt.Sprint(err), poolErrorStatus(err))
+		return
+	}
 
 	w.Header().Set("Content-Type", rpcContentType)
 	w.WriteHeader(http.StatusOK)
 }
 
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	pool := mux.Vars(r)["pool"]
+	if pool == "" {
+		http.Error(w, "Invalid pool name", http.StatusBadRequest)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	lease, err := s.pools.Heartbeat(pool, itemID(id))
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), poolErrorStatus(err))
+		return
+	}
+
+	buf, err := json.Marshal(lease)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", rpcContentType)
+	w.Write(buf)
+}
+
+func (s *Server) handleItemLogs(w http.ResponseWriter, r *http.Request) {
+	pool := mux.Vars(r)["pool"]
+	id := mux.Vars(r)["id"]
+
+	follow := false
+	if val := r.URL.Query().Get("follow"); val != "" {
+		var err error
+		if follow, err = strconv.ParseBool(val); err != nil {
+			http.Error(w, fmt.Sprintf("invalid follow: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	logs, err := s.pools.Logs(pool, itemID(id), follow)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), poolErrorStatus(err))
+		return
+	}
+	defer logs.Close()
+
+	// stop following when the client goes away.
+	go func() {
+		<-r.Context().Done()
+		logs.Close()
+	}()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	var out io.Writer = w
+	if flusher, ok := w.(http.Flusher); ok && follow {
+		out = flushWriter{w, flusher}
+	}
+
+	io.Copy(out, logs)
+}
+
+// flushWriter flushes each write so that followed
+// logs are sent as they arrive.
+type flushWriter struct {
+	w io.Writer
+	f http.Flusher
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.f.Flush()
+	return n, err
+}
+
+func (s *Server) handlePools(w http.ResponseWriter, r *http.Request) {
+	if s.status == nil {
+		http.Error(w, "Status not available", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, s.status.Pools())
+}
+
+func (s *Server) handlePool(w http.ResponseWriter, r *http.Request) {
+	if s.status == nil {
+		http.Error(w, "Status not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	status, ok := s.status.Pool(mux.Vars(r)["pool"])
+	if !ok {
+		http.Error(w, "Pool not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, status)
+}
+
+func (s *Server) handlePoolItems(w http.ResponseWriter, r *http.Request) {
+	if s.status == nil {
+		http.Error(w, "Status not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	items, ok := s.status.Items(mux.Vars(r)["pool"])
+	if !ok {
+		http.Error(w, "Pool not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, items)
+}
+
+func writeJSON(w http.ResponseWriter, obj interface{}) {
+	buf, err := json.Marshal(obj)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", rpcContentType)
+	w.Write(buf)
+}
+
+// unknown pools and items are not found; items that aren't
+// checked out (never checked out, already returned, or
+// reclaimed after their lease expired) are a conflict.
+func poolErrorStatus(err error) int {
+	switch err {
+	case ephemerald.ErrPoolNotFound, ephemerald.ErrItemNotFound:
+		return http.StatusNotFound
+	case ephemerald.ErrNotCheckedOut:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// the lease holder is taken from the "holder" query parameter,
+// falling back to the client's address.  "timeout" limits how
+// long to wait for items to become available.
+func checkoutOptions(r *http.Request) ([]ephemerald.CheckoutOption, error) {
+	query := r.URL.Query()
+
+	holder := query.Get("holder")
+	if holder == "" {
+		holder = r.RemoteAddr
+	}
+
+	opts := []ephemerald.CheckoutOption{ephemerald.WithHolder(holder)}
+
+	if val := query.Get("timeout"); val != "" {
+		timeout, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %v", err)
+		}
+		opts = append(opts, ephemerald.WithTimeout(timeout))
+	}
+
+	return opts, nil
+}
+
 type itemID string
 
 func (i itemID) ID() string {
@@ -0,0 +1,147 @@
+package ephemerald
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"syscall"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+// labels applied to every container created by ephemerald.
+const (
+	labelPool     = "ephemerald.pool"
+	labelConfig   = "ephemerald.config"
+	labelInstance = "ephemerald.instance"
+
+	// host and process ID of the server that created the
+	// container; used to tell whether it is still running.
+	labelHost = "ephemerald.host"
+	labelPID  = "ephemerald.pid"
+)
+
+var (
+	// identifies containers created by this process.
+	instanceID = newInstanceID()
+
+	instanceHost, _ = os.Hostname()
+	instancePID     = os.Getpid()
+
+	// treat containers created on other hosts as orphans.
+	orphansAnyHost = false
+)
+
+// InstanceID returns the identifier that containers created
+// by this process are labeled with.
+func InstanceID() string {
+	return instanceID
+}
+
+func newInstanceID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// SetOrphansAnyHost sets whether containers created by servers on other
+// hosts are considered orphans.  By default they are assumed to belong to
+// a running server; enable this when the hostname isn't stable, such as
+// when ephemerald itself runs in a container.  Must be called before any
+// pools are created.
+func SetOrphansAnyHost(value bool) {
+	orphansAnyHost = value
+}
+
+// isOrphan returns true if the container with the given labels was
+// created by an ephemerald process that is no longer running.
+func isOrphan(labels map[string]string) bool {
+	return orphanedBy(labels, orphansAnyHost, processRunning)
+}
+
+// orphanedBy returns true if the server that created a container isn't
+// running according to running.  Servers on other hosts are assumed to be
+// running unless anyHost is set.  Containers without a host or process ID
+// are orphans.
+func orphanedBy(labels map[string]string, anyHost bool, running func(int) bool) bool {
+	if labels[labelInstance] == instanceID {
+		return false
+	}
+
+	host, ok := labels[labelHost]
+	if !ok {
+		return true
+	}
+	if host != instanceHost {
+		return anyHost
+	}
+
+	pid, err := strconv.Atoi(labels[labelPID])
+	if err != nil {
+		return true
+	}
+
+	// a previous server with the same process ID (eg, when run as
+	// the entrypoint of a container that was restarted).
+	if pid == instancePID {
+		return true
+	}
+
+	return !running(pid)
+}
+
+// processRunning returns true if a process with the given ID exists.
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// CleanupOrphans kills every container left running by an ephemerald
+// process that has exited and returns the IDs of the containers killed.
+// Containers belonging to servers that are still running are left alone.
+func CleanupOrphans(log logrus.FieldLogger) ([]string, error) {
+	log = log.WithField("component", "cleanup")
+
+	client, err := client.NewEnvClient()
+	if err != nil {
+		log.WithError(err).Error("unable to create docker client")
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	f := filters.NewArgs()
+	f.Add("label", labelPool)
+
+	containers, err := client.ContainerList(ctx, types.ContainerListOptions{Filters: f})
+	if err != nil {
+		log.WithError(err).Error("unable to list containers")
+		return nil, err
+	}
+
+	var killed []string
+	for _, c := range containers {
+		if !isOrphan(c.Labels) {
+			continue
+		}
+
+		clog := lcid(log, c.ID).WithField("pool", c.Labels[labelPool])
+
+		if err := client.ContainerKill(ctx, c.ID, "KILL"); err != nil {
+			clog.WithError(err).Error("unable to kill orphaned container")
+			return killed, err
+		}
+
+		clog.Info("killed orphaned container")
+		killed = append(killed, c.ID)
+	}
+
+	return killed, nil
+}
@@ -0,0 +1,109 @@
+package ephemerald
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrphanedBy(t *testing.T) {
+	running := func(pid int) bool { return pid == 100 }
+
+	labels := func(instance, host string, pid int) map[string]string {
+		return map[string]string{
+			labelInstance: instance,
+			labelHost:     host,
+			labelPID:      strconv.Itoa(pid),
+		}
+	}
+
+	// created by this process
+	assert.False(t, orphanedBy(labels(instanceID, instanceHost, instancePID), false, running))
+
+	// server still running
+	assert.False(t, orphanedBy(labels("other", instanceHost, 100), false, running))
+
+	// server on another host
+	assert.False(t, orphanedBy(labels("other", instanceHost+"-other", 200), false, running))
+
+	// server exited
+	assert.True(t, orphanedBy(labels("other", instanceHost, 200), false, running))
+
+	// previous server with the same process ID
+	assert.True(t, orphanedBy(labels("other", instanceHost, instancePID), false, running))
+
+	// server on another host, with any host allowed
+	assert.True(t, orphanedBy(labels("other", instanceHost+"-other", 100), true, running))
+	assert.False(t, orphanedBy(labels(instanceID, instanceHost, instancePID), true, running))
+	assert.False(t, orphanedBy(labels("other", instanceHost, 100), true, running))
+
+	// created without liveness labels
+	assert.True(t, orphanedBy(map[string]string{labelInstance: "other"}, false, running))
+	assert.True(t, orphanedBy(map[string]string{labelInstance: "other", labelHost: instanceHost}, false, running))
+}
+
+func TestPool_adoptOrphans(t *testing.T) {
+	cmd := exec.Command("true")
+	require.NoError(t, cmd.Run())
+	exited := cmd.Process.Pid
+
+	container := func(id string, pid int) types.Container {
+		return types.Container{
+			ID: id,
+			Labels: map[string]string{
+				labelInstance: "other",
+				labelConfig:   "other",
+				labelHost:     instanceHost,
+				labelPID:      strconv.Itoa(pid),
+			},
+		}
+	}
+
+	live := "live00000000"
+	dead := "dead00000000"
+
+	adapter := &orphanAdapter{
+		testAdapter: testAdapter{log: logrus.New()},
+		containers: []types.Container{
+			container(live, os.Getppid()),
+			container(dead, exited),
+		},
+	}
+
+	_, pool := startTestPoolWith(t, `"size":1`, adapter, 0)
+	defer pool.Stop()
+
+	assert.Equal(t, []string{dead}, adapter.getKilled())
+}
+
+// orphanAdapter lists the given containers and records those killed.
+type orphanAdapter struct {
+	testAdapter
+	containers []types.Container
+	killed     []string
+	mtx        sync.Mutex
+}
+
+func (a *orphanAdapter) listContainers() ([]types.Container, error) {
+	return a.containers, nil
+}
+
+func (a *orphanAdapter) containerKill(id string, _ string) error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.killed = append(a.killed, id)
+	return nil
+}
+
+func (a *orphanAdapter) getKilled() []string {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return append([]string(nil), a.killed...)
+}
@@ -3,6 +3,7 @@ package params
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/url"
 	"strconv"
 	"text/template"
@@ -44,12 +45,23 @@ func ParseConfig(buf []byte) (Config, error) {
 	return cfg, nil
 }
 
-func (c Config) ParamsFor(id string, status types.ContainerJSON, port int) (Params, error) {
+// ParamsFor creates the params for a container.  port is the primary
+// container port and ports maps names to container ports.
+func (c Config) ParamsFor(id string, status types.ContainerJSON, port int, ports map[string]int) (Params, error) {
 	p := Params{
 		Config: c,
 		Id:     id,
 		Port:   TCPPortFor(status, port),
 	}
+
+	if len(ports) > 0 {
+		tcpPorts := TCPPortsFor(status)
+		p.Ports = make(map[string]string)
+		for name, port := range ports {
+			p.Ports[name] = tcpPorts[strconv.Itoa(port)]
+		}
+	}
+
 	return p.ForHost(defaultHostname)
 }
 
@@ -57,6 +69,10 @@ type Params struct {
 	Id       string `json:"id"`
 	Hostname string `json:"hostname"`
 	Port     string `json:"port"`
+
+	// host ports by name
+	Ports map[string]string `json:"ports,omitempty"`
+
 	Config
 }
 
@@ -66,6 +82,19 @@ func (p Params) ID() string {
 	return p.Id
 }
 
+// PortFor returns the host port for the named port, or the
+// primary port if name is empty.
+func (p Params) PortFor(name string) (string, error) {
+	if name == "" {
+		return p.Port, nil
+	}
+	port, ok := p.Ports[name]
+	if !ok {
+		return "", fmt.Errorf("unknown port '%v'", name)
+	}
+	return port, nil
+}
+
 func (p Params) ForHost(host string) (Params, error) {
 	p.Hostname = host
 	url, err := p.generateURL()
@@ -95,10 +124,19 @@ func (p Params) generateURL() (string, error) {
 }
 
 func (p Params) queryEscape() Params {
+	var ports map[string]string
+	if p.Ports != nil {
+		ports = make(map[string]string)
+		for name, port := range p.Ports {
+			ports[name] = url.QueryEscape(port)
+		}
+	}
+
 	return Params{
 		Id:       url.QueryEscape(p.Id),
 		Hostname: url.QueryEscape(p.Hostname),
 		Port:     url.QueryEscape(p.Port),
+		Ports:    ports,
 		Config: Config{
 			Username: url.QueryEscape(p.Username),
 			Password: url.QueryEscape(p.Password),
@@ -39,3 +39,52 @@ func TestTCPPorts(t *testing.T) {
 
 	assert.Equal(t, "32768", ports["5432"])
 }
+
+func TestParamsFor_ports(t *testing.T) {
+	buf := testutil.ReadJSON(t, "inspect.postgres.json")
+	var status types.ContainerJSON
+	require.NoError(t, json.Unmarshal(buf, &status))
+
+	cfg, err := params.ParseConfig([]byte(`{"url":"postgres://{{.Hostname}}:{{.Ports.db}}/"}`))
+	require.NoError(t, err)
+
+	p, err := cfg.ParamsFor("id", status, 5432, map[string]int{"db": 5432})
+	require.NoError(t, err)
+
+	assert.Equal(t, "32768", p.Port)
+	assert.Equal(t, "32768", p.Ports["db"])
+	assert.Equal(t, "postgres://localhost:32768/", p.Url)
+
+	port, err := p.PortFor("db")
+	require.NoError(t, err)
+	assert.Equal(t, "32768", port)
+
+	port, err = p.PortFor("")
+	require.NoError(t, err)
+	assert.Equal(t, "32768", port)
+
+	_, err = p.PortFor("admin")
+	assert.Error(t, err)
+}
+
+func TestParamsFor_namedPorts(t *testing.T) {
+	buf := testutil.ReadJSON(t, "inspect.postgres.json")
+	var status types.ContainerJSON
+	require.NoError(t, json.Unmarshal(buf, &status))
+
+	cfg, err := params.ParseConfig([]byte(`{"url":"http://{{.Hostname}}:{{.Ports.api}}/?db={{.Ports.db}}"}`))
+	require.NoError(t, err)
+
+	// ports that aren't published are empty.
+	p, err := cfg.ParamsFor("id", status, 5432, map[string]int{"db": 5432, "api": 8080})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"db": "32768", "api": ""}, p.Ports)
+	assert.Equal(t, "http://localhost:/?db=32768", p.Url)
+
+	// named ports are escaped like the rest of the url.
+	p.Ports = map[string]string{"db": "a b&c", "api": "80"}
+	p, err = p.ForHost("example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com:80/?db=a+b%26c", p.Url)
+	assert.Equal(t, "a b&c", p.Ports["db"])
+}
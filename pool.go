@@ -3,6 +3,7 @@ package ephemerald
 import (
 	"context"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -16,12 +17,17 @@ var (
 	errImagePull      = fmt.Errorf("error pulling docker image")
 	errNotRunning     = fmt.Errorf("pool not running")
 	errNotInitialized = fmt.Errorf("pool not initialized")
+
+	ErrItemNotFound  = fmt.Errorf("item not found")
+	ErrNotCheckedOut = fmt.Errorf("item not checked out")
 )
 
 type Pool interface {
-	Checkout() (params.Params, error)
-	CheckoutWith(context.Context) (params.Params, error)
-	Return(Item)
+	Checkout(...CheckoutOption) (params.Params, error)
+	CheckoutWith(context.Context, ...CheckoutOption) (params.Params, error)
+	Heartbeat(Item) (Lease, error)
+	Return(Item) error
+	Logs(Item, bool) (io.ReadCloser, error)
 	Stop() error
 	WaitReady() error
 }
@@ -38,9 +44,21 @@ type StatusItem interface {
 type poolEventID string
 
 const (
-	eventItemReady    poolEventID = "ready"
-	eventItemReturned poolEventID = "returned"
-	eventItemExit     poolEventID = "exit"
+	eventItemReady poolEventID = "ready"
+	eventItemExit  poolEventID = "exit"
+
+	eventCheckoutWait   poolEventID = "checkout-wait"
+	eventCheckoutCancel poolEventID = "checkout-cancel"
+	eventItemCheckedOut poolEventID = "checked-out"
+)
+
+const (
+	// how often to look for expired leases and idle items
+	poolTickInterval = time.Second
+
+	// allowance for creating and starting a container when
+	// a checkout must wait for the pool to grow.
+	containerStartTimeout = 10 * time.Second
 )
 
 type poolState string
@@ -54,6 +72,34 @@ const (
 type poolEvent struct {
 	id   poolEventID
 	item Item
+
+	// set for eventItemCheckedOut
+	holder string
+}
+
+type poolLeaseOp string
+
+const (
+	leaseOpExtend  poolLeaseOp = "extend"
+	leaseOpRelease poolLeaseOp = "release"
+)
+
+type poolLeaseRequest struct {
+	op poolLeaseOp
+	id string
+	ch chan<- poolLeaseResult
+}
+
+type poolLeaseResult struct {
+	lease Lease
+	err   error
+}
+
+// lookup of a live item.  nil is sent if
+// the item is not found.
+type poolItemRequest struct {
+	id string
+	ch chan<- poolItem
 }
 
 type pool struct {
@@ -62,7 +108,10 @@ type pool struct {
 	config *config.Config
 
 	// number of items to maintain
-	size int
+	target int
+
+	// number of clients waiting in CheckoutWith
+	numWaiting int
 
 	// docker client
 	adapter dockerAdapter
@@ -70,6 +119,12 @@ type pool struct {
 	// mainloop events
 	events chan poolEvent
 
+	// lease extension and release requests
+	leasech chan poolLeaseRequest
+
+	// item lookups
+	itemch chan poolItemRequest
+
 	// manages creating new items
 	spawner poolItemSpawner
 
@@ -91,6 +146,9 @@ type pool struct {
 	// "alive" items
 	items map[string]poolItem
 
+	// leases for items currently checked out by clients
+	checkedOut map[string]*poolLease
+
 	ctx context.Context
 
 	log logrus.FieldLogger
@@ -103,6 +161,13 @@ func NewPool(config *config.Config) (Pool, error) {
 }
 
 func NewPoolWithContext(ctx context.Context, config *config.Config) (Pool, error) {
+	if config.Shared != nil {
+		return newSharedPool(ctx, config)
+	}
+	return newPool(ctx, config, config.Emitter())
+}
+
+func newPool(ctx context.Context, config *config.Config, uie ui.PoolEmitter) (*pool, error) {
 
 	adapter, err := newDockerAdapter(config)
 	if err != nil {
@@ -110,26 +175,34 @@ func NewPoolWithContext(ctx context.Context, config *config.Config) (Pool, error
 		return nil, err
 	}
 
-	log := adapter.logger().WithField("component", "Pool")
+	spawner := newPoolItemSpawner(uie, adapter.logger(), func() (poolItem, error) {
+		return createPoolItem(uie, adapter.logger(), adapter, config.Lifecycle)
+	})
+
+	return startPool(ctx, config, uie, adapter, spawner), nil
+}
 
-	uie := config.Emitter()
+func startPool(ctx context.Context, config *config.Config, uie ui.PoolEmitter, adapter dockerAdapter, spawner poolItemSpawner) *pool {
+	log := adapter.logger().WithField("component", "Pool")
 
 	p := &pool{
 		state:   stateInitializing,
 		config:  config,
-		size:    config.Size,
 		adapter: adapter,
 
 		readybuf: newPoolItemBuffer(uie),
-		spawner:  newPoolItemSpawner(uie, adapter, config.Lifecycle),
+		spawner:  spawner,
 
-		events: make(chan poolEvent),
+		events:  make(chan poolEvent),
+		leasech: make(chan poolLeaseRequest),
+		itemch:  make(chan poolItemRequest),
 
 		initch:     make(chan bool),
 		shutdownch: make(chan bool),
 		donech:     make(chan bool),
 
-		items: make(map[string]poolItem),
+		items:      make(map[string]poolItem),
+		checkedOut: make(map[string]*poolLease),
 
 		ctx: ctx,
 
@@ -142,7 +215,7 @@ func NewPoolWithContext(ctx context.Context, config *config.Config) (Pool, error
 	go p.run()
 	go p.monitorCtx()
 
-	return p, nil
+	return p
 }
 
 func (p *pool) Stop() error {
@@ -163,39 +236,136 @@ func (p *pool) WaitReady() error {
 	}
 }
 
-func (p *pool) Checkout() (params.Params, error) {
-	ctx, cancel := context.WithTimeout(p.ctx, p.defaultCheckoutTimeout())
-	defer cancel()
-	return p.CheckoutWith(ctx)
+func (p *pool) Checkout(opts ...CheckoutOption) (params.Params, error) {
+	opts = append([]CheckoutOption{WithTimeout(p.defaultCheckoutTimeout())}, opts...)
+	return p.CheckoutWith(p.ctx, opts...)
 }
 
-func (p *pool) CheckoutWith(ctx context.Context) (params.Params, error) {
+// CheckoutWith waits for a ready item.  Waiting clients are
+// served in the order that they arrive.
+func (p *pool) CheckoutWith(ctx context.Context, opts ...CheckoutOption) (params.Params, error) {
+	options := newCheckoutOptions(opts)
+
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+
 	select {
 	case <-ctx.Done():
 		return params.Params{}, ctx.Err()
-	case item, ok := <-p.readybuf.get():
-		if !ok {
-			return params.Params{}, errNotRunning
-		}
-		result, err := p.adapter.makeParams(item)
+	case <-p.donech:
+		return params.Params{}, errNotRunning
+	case p.events <- poolEvent{eventCheckoutWait, nil, ""}:
+	}
+
+	item, err := p.checkoutItem(ctx)
+	if err != nil {
+		p.sendEvent(poolEvent{eventCheckoutCancel, nil, ""})
+		return params.Params{}, err
+	}
+
+	p.sendEvent(poolEvent{eventItemCheckedOut, item, options.holder})
+
+	result, err := p.adapter.makeParams(item)
+	if err != nil {
+		p.Return(item)
+		return params.Params{}, err
+	}
+	lcid(p.log, item.ID()).Info("checked out")
+	return result, nil
+}
+
+// checkoutItem waits for a ready item that passes its on-checkout
+// hook.  Items that fail the hook are killed and replaced.
+func (p *pool) checkoutItem(ctx context.Context) (poolItem, error) {
+	for {
+		item, err := p.readybuf.get(ctx)
 		if err != nil {
-			p.Return(item)
-			return params.Params{}, err
+			return nil, err
+		}
+
+		if err := item.checkout(ctx); err != nil {
+			if ctx.Err() != nil {
+				// not the item's fault; make it available again.
+				p.sendEvent(poolEvent{eventItemReady, item, ""})
+				return nil, ctx.Err()
+			}
+			item.kill()
+			continue
 		}
-		lcid(p.log, item.ID()).Info("checked out")
-		return result, nil
+
+		return item, nil
 	}
 }
 
-func (p *pool) Return(i Item) {
+// Heartbeat extends the lease of a checked-out item.
+func (p *pool) Heartbeat(i Item) (Lease, error) {
+	return p.leaseRequest(leaseOpExtend, i)
+}
+
+// Return releases a checked-out item back to the pool.
+// ErrItemNotFound is returned for items that don't belong
+// to the pool and ErrNotCheckedOut for items that aren't
+// currently checked out.
+func (p *pool) Return(i Item) error {
+	_, err := p.leaseRequest(leaseOpRelease, i)
+	return err
+}
+
+// Logs returns a reader of the output of an item.  If follow
+// is true, the reader blocks for new output until the item exits.
+func (p *pool) Logs(i Item, follow bool) (io.ReadCloser, error) {
+	item, err := p.lookupItem(i)
+	if err != nil {
+		return nil, err
+	}
+	return item.logs().reader(follow), nil
+}
+
+// lookupItem returns the live item with the same ID as i.
+func (p *pool) lookupItem(i Item) (poolItem, error) {
+	ch := make(chan poolItem, 1)
 	select {
 	case <-p.donech:
-	case p.events <- poolEvent{eventItemReturned, i}:
+		return nil, errNotRunning
+	case p.itemch <- poolItemRequest{i.ID(), ch}:
 	}
+
+	item := <-ch
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
+	return item, nil
 }
 
+func (p *pool) leaseRequest(op poolLeaseOp, i Item) (Lease, error) {
+	ch := make(chan poolLeaseResult, 1)
+	select {
+	case <-p.donech:
+		return Lease{}, errNotRunning
+	case p.leasech <- poolLeaseRequest{op, i.ID(), ch}:
+		result := <-ch
+		return result.lease, result.err
+	}
+}
+
+func (p *pool) sendEvent(e poolEvent) {
+	select {
+	case <-p.donech:
+	case p.events <- e:
+	}
+}
+
+// defaultCheckoutTimeout is long enough for a ready item to be reset.
+// Pools that grow on demand also wait for a new item to be started.
 func (p *pool) defaultCheckoutTimeout() time.Duration {
-	return p.config.Lifecycle.MaxDelay() + (500 * time.Millisecond)
+	timeout := p.config.Lifecycle.MaxDelay() + (500 * time.Millisecond)
+	if p.config.MinSize < p.config.MaxSize {
+		timeout += containerStartTimeout + p.config.Lifecycle.StartDelay()
+	}
+	return timeout
 }
 
 func (p *pool) monitorCtx() {
@@ -241,12 +411,23 @@ func (p *pool) runInitialize() error {
 
 	p.state = stateRunning
 
+	p.adoptOrphans()
+
+	target := p.config.MinSize
+	if len(p.items) > target {
+		target = len(p.items)
+	}
+
+	p.setTarget(target)
 	p.primeBacklog()
 
 	return nil
 }
 
 func (p *pool) runRunning() {
+	ticker := time.NewTicker(poolTickInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-p.shutdownch:
@@ -266,6 +447,16 @@ func (p *pool) runRunning() {
 			item.start()
 			p.uie.EmitNumItems(len(p.items))
 
+		case <-ticker.C:
+			p.expireLeases()
+			p.scaleDown()
+
+		case req := <-p.leasech:
+			req.ch <- p.handleLeaseRequest(req)
+
+		case req := <-p.itemch:
+			req.ch <- p.items[req.id]
+
 		case e := <-p.events:
 
 			p.debugEvent(e, "running")
@@ -278,16 +469,24 @@ func (p *pool) runRunning() {
 					p.readybuf.put(i)
 				}
 
-			case eventItemReturned:
-				if i, ok := p.items[e.item.ID()]; ok {
-					lcid(p.log, e.item.ID()).Info("returned")
-					i.reset()
-				}
-
 			case eventItemExit:
 				delete(p.items, e.item.ID())
+				p.removeLease(e.item.ID())
 				p.uie.EmitNumItems(len(p.items))
 				p.primeBacklog()
+
+			case eventCheckoutWait:
+				p.setWaiting(p.numWaiting + 1)
+				p.scaleUp()
+
+			case eventCheckoutCancel:
+				p.setWaiting(p.numWaiting - 1)
+
+			case eventItemCheckedOut:
+				p.setWaiting(p.numWaiting - 1)
+				if i, ok := p.items[e.item.ID()]; ok {
+					p.addLease(newPoolLease(i, e.holder, p.config.LeaseTTL))
+				}
 			}
 		}
 	}
@@ -307,6 +506,10 @@ func (p *pool) runDrainSpawner() {
 			item.kill()
 		case e := <-p.events:
 			p.handleDrainingEvent(e, "drain-spawn")
+		case req := <-p.leasech:
+			req.ch <- poolLeaseResult{Lease{}, errNotRunning}
+		case req := <-p.itemch:
+			req.ch <- p.items[req.id]
 		}
 	}
 }
@@ -324,6 +527,10 @@ func (p *pool) runDrainItems() {
 		select {
 		case e := <-p.events:
 			p.handleDrainingEvent(e, "drain-chilren")
+		case req := <-p.leasech:
+			req.ch <- poolLeaseResult{Lease{}, errNotRunning}
+		case req := <-p.itemch:
+			req.ch <- p.items[req.id]
 		}
 	}
 }
@@ -332,8 +539,6 @@ func (p *pool) handleDrainingEvent(e poolEvent, msg string) {
 	p.debugEvent(e, msg)
 	switch e.id {
 	case eventItemReady:
-		fallthrough
-	case eventItemReturned:
 		if i, ok := p.items[e.item.ID()]; ok {
 			i.kill()
 		}
@@ -344,9 +549,155 @@ func (p *pool) handleDrainingEvent(e poolEvent, msg string) {
 }
 
 func (p *pool) primeBacklog() {
-	if current := len(p.items); current < p.size {
-		p.spawner.request(p.size - current)
+	needed := p.target - len(p.items)
+	if needed < 0 {
+		needed = 0
+	}
+	p.spawner.request(needed)
+}
+
+// adoptOrphans takes over containers that an ephemerald process
+// that is no longer running left behind for this pool.  Containers created with the
+// current config are adopted after passing their healthcheck and
+// reset actions; the rest are killed.
+func (p *pool) adoptOrphans() {
+	containers, err := p.adapter.listContainers()
+	if err != nil {
+		p.log.WithError(err).Warn("unable to list orphaned containers")
+		return
+	}
+
+	for _, c := range containers {
+		if !isOrphan(c.Labels) {
+			continue
+		}
+
+		log := lcid(p.log, c.ID)
+
+		if c.Labels[labelConfig] != p.config.Hash || len(p.items) >= p.config.MaxSize {
+			log.Info("killing orphaned container")
+			if err := p.adapter.containerKill(c.ID, "KILL"); err != nil {
+				log.WithError(err).Warn("unable to kill orphaned container")
+			}
+			continue
+		}
+
+		log.Info("adopting orphaned container")
+
+		item := adoptPoolItem(p.uie, p.log, p.adapter, p.config.Lifecycle, c.ID)
+		p.items[item.ID()] = item
+		item.join(p.events)
+		item.start()
+	}
+
+	p.uie.EmitNumItems(len(p.items))
+}
+
+// scaleUp grows the pool so that every waiting client
+// can be served, up to the configured maximum.
+func (p *pool) scaleUp() {
+	demand := len(p.checkedOut) + p.numWaiting
+	if demand > p.config.MaxSize {
+		demand = p.config.MaxSize
+	}
+	if demand <= p.target {
+		return
+	}
+
+	p.log.Infof("scaling up: %v -> %v (%v waiting)", p.target, demand, p.numWaiting)
+
+	p.setTarget(demand)
+	p.primeBacklog()
+}
+
+// scaleDown kills ready items that have been idle for longer
+// than the cool-down, down to the configured minimum.
+func (p *pool) scaleDown() {
+	if p.numWaiting > 0 || p.target <= p.config.MinSize {
+		return
+	}
+
+	items := p.readybuf.removeIdle(p.target-p.config.MinSize, p.config.Cooldown)
+	if len(items) == 0 {
+		return
+	}
+
+	p.log.Infof("scaling down: %v -> %v", p.target, p.target-len(items))
+
+	p.setTarget(p.target - len(items))
+	p.primeBacklog()
+
+	for _, item := range items {
+		lcid(p.log, item.ID()).Debug("killing idle item")
+		item.kill()
+	}
+}
+
+func (p *pool) handleLeaseRequest(req poolLeaseRequest) poolLeaseResult {
+	if _, ok := p.items[req.id]; !ok {
+		return poolLeaseResult{Lease{}, ErrItemNotFound}
+	}
+
+	lease, ok := p.checkedOut[req.id]
+	if !ok {
+		return poolLeaseResult{Lease{}, ErrNotCheckedOut}
+	}
+
+	switch req.op {
+	case leaseOpExtend:
+		lease.extend()
+	case leaseOpRelease:
+		lcid(p.log, req.id).Info("returned")
+		p.removeLease(req.id)
+		lease.item.reset()
 	}
+
+	return poolLeaseResult{lease.Lease, nil}
+}
+
+// expireLeases reclaims items whose holders have stopped
+// extending their lease.
+func (p *pool) expireLeases() {
+	now := time.Now()
+	for id, lease := range p.checkedOut {
+		if !lease.expired(now) {
+			continue
+		}
+
+		lcid(p.log, id).
+			WithField("holder", lease.Holder).
+			WithField("expires", lease.Expires).
+			Warn("lease expired")
+
+		p.uie.ForContainer(id).EmitLeaseExpired(lease.Holder)
+
+		p.removeLease(id)
+		lease.item.reset()
+	}
+}
+
+func (p *pool) addLease(lease *poolLease) {
+	p.checkedOut[lease.ID] = lease
+	p.uie.ForContainer(lease.ID).EmitCheckedOut()
+	p.uie.EmitNumCheckedOut(len(p.checkedOut))
+}
+
+func (p *pool) removeLease(id string) {
+	if _, ok := p.checkedOut[id]; !ok {
+		return
+	}
+	delete(p.checkedOut, id)
+	p.uie.EmitNumCheckedOut(len(p.checkedOut))
+}
+
+func (p *pool) setWaiting(count int) {
+	p.numWaiting = count
+	p.uie.EmitNumWaiting(count)
+}
+
+func (p *pool) setTarget(target int) {
+	p.target = target
+	p.uie.EmitTargetSize(target)
 }
 
 func (p *pool) killItems() {
@@ -0,0 +1,203 @@
+package ephemerald
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/boz/ephemerald/config"
+	"github.com/boz/ephemerald/params"
+	"github.com/boz/ephemerald/ui"
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool_scaleUp(t *testing.T) {
+	items, pool := startTestPool(t, `"min":0,"max":2`, 0)
+	defer pool.Stop()
+
+	assert.Equal(t, 0, items.numCreated())
+
+	first, err := pool.Checkout()
+	require.NoError(t, err)
+
+	second, err := pool.Checkout()
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Id, second.Id)
+
+	// at max size
+	_, err = pool.Checkout(WithTimeout(50 * time.Millisecond))
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 2, items.numCreated())
+
+	require.NoError(t, pool.Return(first))
+
+	p, err := pool.Checkout()
+	require.NoError(t, err)
+	assert.Equal(t, first.Id, p.Id)
+	assert.Equal(t, 2, items.numCreated())
+}
+
+func TestPool_maxSize(t *testing.T) {
+	items, pool := startTestPool(t, `"min":0,"max":3`, 20*time.Millisecond)
+	defer pool.Stop()
+
+	var wg sync.WaitGroup
+	results := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pool.Checkout(WithTimeout(500 * time.Millisecond))
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	count := 0
+	for err := range results {
+		if err == nil {
+			count++
+		}
+	}
+
+	assert.Equal(t, 3, count)
+	assert.Equal(t, 3, items.numCreated())
+}
+
+func TestPool_scaleDown(t *testing.T) {
+	items, pool := startTestPool(t, `"min":1,"max":3,"cooldown":"10ms"`, 0)
+	defer pool.Stop()
+
+	var checkouts []params.Params
+	for i := 0; i < 3; i++ {
+		p, err := pool.Checkout()
+		require.NoError(t, err)
+		checkouts = append(checkouts, p)
+	}
+	assert.Equal(t, 3, items.numLive())
+
+	for _, p := range checkouts {
+		require.NoError(t, pool.Return(p))
+	}
+
+	deadline := time.Now().Add(3 * poolTickInterval)
+	for items.numLive() > 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 1, items.numLive())
+	assert.Equal(t, 3, items.numCreated())
+
+	_, err := pool.Checkout()
+	require.NoError(t, err)
+	assert.Equal(t, 3, items.numCreated())
+}
+
+func TestPool_defaultCheckoutTimeout(t *testing.T) {
+	_, fixed := startTestPool(t, `"size":1`, 0)
+	defer fixed.Stop()
+
+	_, scaled := startTestPool(t, `"min":0,"max":1`, 0)
+	defer scaled.Stop()
+
+	assert.Equal(t, 500*time.Millisecond, fixed.defaultCheckoutTimeout())
+	assert.Equal(t, 500*time.Millisecond+containerStartTimeout, scaled.defaultCheckoutTimeout())
+}
+
+func startTestPool(t *testing.T, opts string, delay time.Duration) (*testItemFactory, *pool) {
+	return startTestPoolWith(t, opts, testAdapter{log: logrus.New()}, delay)
+}
+
+func startTestPoolWith(t *testing.T, opts string, adapter dockerAdapter, delay time.Duration) (*testItemFactory, *pool) {
+	buf := []byte(`{"image":"test","port":1,` + opts + `}`)
+
+	cfg, err := config.Parse(logrus.New(), ui.NewNoopEmitter(), t.Name(), buf)
+	require.NoError(t, err)
+
+	items := &testItemFactory{delay: delay}
+
+	pool := startPool(context.Background(), cfg, cfg.Emitter(), adapter,
+		newPoolItemSpawner(cfg.Emitter(), adapter.logger(), items.create))
+	require.NoError(t, pool.WaitReady())
+
+	return items, pool
+}
+
+// testAdapter stands in for docker for pools of testPoolItems.
+type testAdapter struct {
+	dockerAdapter
+	log logrus.FieldLogger
+}
+
+func (a testAdapter) ensureImage() error                         { return nil }
+func (a testAdapter) listContainers() ([]types.Container, error) { return nil, nil }
+func (a testAdapter) logger() logrus.FieldLogger                 { return a.log }
+
+func (a testAdapter) makeParams(i StatusItem) (params.Params, error) {
+	return params.Params{Id: i.ID()}, nil
+}
+
+// testItemFactory creates testPoolItems and counts the live ones.
+// IDs are padded to the length that log fields expect.
+type testItemFactory struct {
+	delay   time.Duration
+	created int
+	live    int
+	mtx     sync.Mutex
+}
+
+func (f *testItemFactory) create() (poolItem, error) {
+	time.Sleep(f.delay)
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.created++
+	f.live++
+	return &testPoolItem{testItem: testItem(fmt.Sprintf("%012x", f.created)), factory: f}, nil
+}
+
+func (f *testItemFactory) exited() {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.live--
+}
+
+func (f *testItemFactory) numCreated() int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.created
+}
+
+func (f *testItemFactory) numLive() int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.live
+}
+
+// testPoolItem becomes ready as soon as it is started or
+// reset and exits when killed.
+type testPoolItem struct {
+	testItem
+	factory *testItemFactory
+	ch      chan<- poolEvent
+	once    sync.Once
+}
+
+func (i *testPoolItem) join(ch chan<- poolEvent) { i.ch = ch }
+func (i *testPoolItem) start()                   { go i.send(eventItemReady) }
+func (i *testPoolItem) reset()                   { go i.send(eventItemReady) }
+
+func (i *testPoolItem) kill() {
+	i.once.Do(func() {
+		i.factory.exited()
+		go i.send(eventItemExit)
+	})
+}
+
+func (i *testPoolItem) send(id poolEventID) {
+	i.ch <- poolEvent{id, i, ""}
+}
@@ -2,6 +2,8 @@ package ephemerald
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"sync"
 
 	"github.com/Sirupsen/logrus"
@@ -9,11 +11,18 @@ import (
 	"github.com/boz/ephemerald/params"
 )
 
+var (
+	ErrPoolNotFound = fmt.Errorf("pool not found")
+)
+
 type PoolSet interface {
 	Checkout(name ...string) (params.Set, error)
 	CheckoutWith(ctx context.Context, name ...string) (params.Set, error)
-	ReturnAll(params.Set)
-	Return(name string, item Item)
+	CheckoutWithOptions(ctx context.Context, names []string, opts ...CheckoutOption) (params.Set, error)
+	Heartbeat(name string, item Item) (Lease, error)
+	ReturnAll(params.Set) error
+	Return(name string, item Item) error
+	Logs(name string, item Item, follow bool) (io.ReadCloser, error)
 	WaitReady() error
 	Stop() error
 }
@@ -63,6 +72,10 @@ func NewPoolSet(log logrus.FieldLogger, ctx context.Context, configs []*config.C
 }
 
 func (ps *poolSet) CheckoutWith(ctx context.Context, names ...string) (params.Set, error) {
+	return ps.CheckoutWithOptions(ctx, names)
+}
+
+func (ps *poolSet) CheckoutWithOptions(ctx context.Context, names []string, opts ...CheckoutOption) (params.Set, error) {
 	type pscheckout struct {
 		name   string
 		params params.Params
@@ -76,7 +89,7 @@ func (ps *poolSet) CheckoutWith(ctx context.Context, names ...string) (params.Se
 		wg.Add(1)
 		go func(name string, pool Pool) {
 			defer wg.Done()
-			params, err := pool.CheckoutWith(ctx)
+			params, err := pool.CheckoutWith(ctx, opts...)
 			ch <- pscheckout{name, params, err}
 		}(name, pool)
 	}
@@ -113,22 +126,54 @@ func (ps *poolSet) Checkout(names ...string) (params.Set, error) {
 	return ps.CheckoutWith(context.Background(), names...)
 }
 
-func (ps *poolSet) ReturnAll(set params.Set) {
-	var wg sync.WaitGroup
-	wg.Add(len(set))
+// ReturnAll returns every item in the set.  The first
+// error encountered is returned.
+func (ps *poolSet) ReturnAll(set params.Set) error {
+	ch := make(chan error, len(set))
 	for name, p := range set {
 		go func(name string, p params.Params) {
-			defer wg.Done()
-			ps.Return(name, p)
+			ch <- ps.Return(name, p)
 		}(name, p)
 	}
-	wg.Wait()
+
+	var err error
+	for range set {
+		if e := <-ch; e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (ps *poolSet) Return(name string, item Item) error {
+	pool, ok := ps.pools[name]
+	if !ok {
+		return ErrPoolNotFound
+	}
+	if err := pool.Return(item); err != nil {
+		ps.log.WithError(err).
+			WithField("pool", name).
+			WithField("item", item.ID()).
+			Warn("return failed")
+		return err
+	}
+	return nil
+}
+
+func (ps *poolSet) Heartbeat(name string, item Item) (Lease, error) {
+	pool, ok := ps.pools[name]
+	if !ok {
+		return Lease{}, ErrPoolNotFound
+	}
+	return pool.Heartbeat(item)
 }
 
-func (ps *poolSet) Return(name string, item Item) {
-	if pool, ok := ps.pools[name]; ok {
-		pool.Return(item)
+func (ps *poolSet) Logs(name string, item Item, follow bool) (io.ReadCloser, error) {
+	pool, ok := ps.pools[name]
+	if !ok {
+		return nil, ErrPoolNotFound
 	}
+	return pool.Logs(item, follow)
 }
 
 func (ps *poolSet) WaitReady() error {
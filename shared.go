@@ -0,0 +1,842 @@
+package ephemerald
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/boz/ephemerald/config"
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/boz/ephemerald/ui"
+)
+
+const (
+	// holder of the containers checked out by a shared pool.
+	sharedHolder = "shared"
+
+	// prefix of the names of databases created by a shared pool.
+	sharedDatabasePrefix = "ephemerald_"
+)
+
+// sharedServerPool provides the containers that a shared pool
+// creates databases in.
+type sharedServerPool interface {
+	CheckoutWith(context.Context, ...CheckoutOption) (params.Params, error)
+	Return(Item) error
+	Logs(Item, bool) (io.ReadCloser, error)
+	Stop() error
+	WaitReady() error
+	lookupItem(Item) (poolItem, error)
+}
+
+// sharedServer is a container checked out by a shared pool.
+type sharedServer struct {
+	params params.Params
+
+	// number of databases created, being created, or
+	// being dropped in the container.
+	databases int
+
+	// reset databases waiting to be checked out again.
+	ready []*sharedDatabase
+
+	// when the last database in use was released.
+	idleSince time.Time
+
+	// set once the container has exited or been given back.
+	dead bool
+
+	// closed when the container is given back.
+	releasech chan bool
+}
+
+func newSharedServer(p params.Params) *sharedServer {
+	return &sharedServer{
+		params:    p,
+		idleSince: time.Now(),
+		releasech: make(chan bool),
+	}
+}
+
+// idle returns true if none of the server's databases
+// are in use.
+func (s *sharedServer) idle() bool {
+	return s.databases == len(s.ready)
+}
+
+type sharedDatabase struct {
+	server *sharedServer
+	params params.Params
+}
+
+func (db *sharedDatabase) ID() string {
+	return db.params.Id
+}
+
+// sharedSlot answers a checkout request with either a ready
+// database or room to create one in server.  server is nil
+// if the pool is shutting down.
+type sharedSlot struct {
+	server *sharedServer
+	db     *sharedDatabase
+}
+
+type sharedRequest struct {
+	ch chan sharedSlot
+}
+
+type sharedEventID string
+
+const (
+	sharedEventCheckoutCancel sharedEventID = "checkout-cancel"
+	sharedEventCheckedOut     sharedEventID = "checked-out"
+	sharedEventCreateError    sharedEventID = "create-error"
+	sharedEventReady          sharedEventID = "ready"
+	sharedEventDropped        sharedEventID = "dropped"
+	sharedEventServer         sharedEventID = "server"
+	sharedEventServerError    sharedEventID = "server-error"
+	sharedEventServerExit     sharedEventID = "server-exit"
+)
+
+type sharedEvent struct {
+	id     sharedEventID
+	req    *sharedRequest
+	server *sharedServer
+	db     *sharedDatabase
+
+	// set for sharedEventCheckedOut
+	holder string
+}
+
+// lookup of a database.  nil is sent if
+// the database is not found.
+type sharedDatabaseRequest struct {
+	id string
+	ch chan<- *sharedDatabase
+}
+
+// sharedPool hands out a database inside one of a few shared
+// containers for each checkout.  Containers are checked out of an
+// ordinary pool; more are added, up to the pool's maximum size, when
+// every container is at capacity.  A container with no databases in
+// use for the pool's cooldown is given back, down to the pool's
+// minimum size.  A container that exits is replaced and the databases
+// in it are forgotten.
+//
+// Returned databases are reset with the pool's reset action and
+// reused.  If there is no reset action, or it fails, the database
+// is dropped.
+type sharedPool struct {
+	state poolState
+
+	config *config.Config
+	shared *config.Shared
+
+	servers sharedServerPool
+
+	// containers that databases are created in
+	active []*sharedServer
+
+	// number of containers being checked out
+	growing int
+
+	// checkout requests waiting for room, in the order they arrived
+	waiting []*sharedRequest
+
+	// live databases
+	databases map[string]*sharedDatabase
+
+	// leases for databases currently checked out by clients
+	checkedOut map[string]*poolLease
+
+	requests chan *sharedRequest
+	events   chan sharedEvent
+	leasech  chan poolLeaseRequest
+	dbch     chan sharedDatabaseRequest
+
+	// closed when initialization complete
+	initch chan bool
+
+	// error during initialization
+	initErr error
+
+	shutdownch chan bool
+	donech     chan bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	log logrus.FieldLogger
+
+	// databases are reported as the pool's containers.
+	uie ui.PoolEmitter
+}
+
+// sharedServerEmitter reports the containers of a shared pool.  The
+// number of waiting and checked-out clients is reported by the
+// shared pool instead.
+type sharedServerEmitter struct {
+	ui.PoolEmitter
+}
+
+func (sharedServerEmitter) EmitNumWaiting(int)    {}
+func (sharedServerEmitter) EmitNumCheckedOut(int) {}
+
+func newSharedPool(ctx context.Context, config *config.Config) (Pool, error) {
+	// containers are held for as long as the shared pool runs.
+	cfg := *config
+	cfg.Shared = nil
+	cfg.LeaseTTL = 0
+
+	servers, err := newPool(ctx, &cfg, sharedServerEmitter{config.Emitter()})
+	if err != nil {
+		return nil, err
+	}
+
+	return startSharedPool(ctx, config, servers), nil
+}
+
+func startSharedPool(ctx context.Context, config *config.Config, servers sharedServerPool) *sharedPool {
+	ctx, cancel := context.WithCancel(ctx)
+
+	p := &sharedPool{
+		state:   stateInitializing,
+		config:  config,
+		shared:  config.Shared,
+		servers: servers,
+
+		databases:  make(map[string]*sharedDatabase),
+		checkedOut: make(map[string]*poolLease),
+
+		requests: make(chan *sharedRequest),
+		events:   make(chan sharedEvent),
+		leasech:  make(chan poolLeaseRequest),
+		dbch:     make(chan sharedDatabaseRequest),
+
+		initch:     make(chan bool),
+		shutdownch: make(chan bool),
+		donech:     make(chan bool),
+
+		ctx:    ctx,
+		cancel: cancel,
+
+		log: config.Log().WithField("component", "SharedPool"),
+		uie: config.Emitter(),
+	}
+
+	go p.run()
+	go p.monitorCtx()
+
+	return p
+}
+
+func (p *sharedPool) Stop() error {
+	select {
+	case p.shutdownch <- true:
+		<-p.donech
+	case <-p.donech:
+	}
+	return nil
+}
+
+func (p *sharedPool) WaitReady() error {
+	select {
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	case <-p.initch:
+		return p.initErr
+	}
+}
+
+func (p *sharedPool) Checkout(opts ...CheckoutOption) (params.Params, error) {
+	opts = append([]CheckoutOption{WithTimeout(p.defaultCheckoutTimeout())}, opts...)
+	return p.CheckoutWith(p.ctx, opts...)
+}
+
+// CheckoutWith waits for room in one of the containers and returns
+// the params of a database created for the caller.  Waiting clients
+// are served in the order that they arrive.
+func (p *sharedPool) CheckoutWith(ctx context.Context, opts ...CheckoutOption) (params.Params, error) {
+	options := newCheckoutOptions(opts)
+
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+
+	req := &sharedRequest{ch: make(chan sharedSlot, 1)}
+
+	select {
+	case <-ctx.Done():
+		return params.Params{}, ctx.Err()
+	case <-p.donech:
+		return params.Params{}, errNotRunning
+	case p.requests <- req:
+	}
+
+	var slot sharedSlot
+
+	select {
+	case <-ctx.Done():
+		p.sendEvent(sharedEvent{id: sharedEventCheckoutCancel, req: req})
+		return params.Params{}, ctx.Err()
+	case <-p.donech:
+		return params.Params{}, errNotRunning
+	case slot = <-req.ch:
+	}
+
+	if slot.server == nil {
+		return params.Params{}, errNotRunning
+	}
+
+	db := slot.db
+	if db == nil {
+		var err error
+		if db, err = p.createDatabase(slot.server); err != nil {
+			return params.Params{}, err
+		}
+
+		// too late; keep the database for the next client.
+		if err := ctx.Err(); err != nil {
+			p.recycle(db)
+			return params.Params{}, err
+		}
+	}
+
+	p.sendEvent(sharedEvent{id: sharedEventCheckedOut, db: db, holder: options.holder})
+
+	p.log.WithField("database", db.params.Database).
+		WithField("container", db.server.params.Id).
+		Info("checked out")
+
+	return db.params, nil
+}
+
+// Heartbeat extends the lease of a checked-out database.
+func (p *sharedPool) Heartbeat(i Item) (Lease, error) {
+	return p.leaseRequest(leaseOpExtend, i)
+}
+
+// Return releases a checked-out database.  It is reset
+// for reuse or dropped.
+func (p *sharedPool) Return(i Item) error {
+	_, err := p.leaseRequest(leaseOpRelease, i)
+	return err
+}
+
+// Logs returns a reader of the output of the container
+// that the database is in.
+func (p *sharedPool) Logs(i Item, follow bool) (io.ReadCloser, error) {
+	ch := make(chan *sharedDatabase, 1)
+	select {
+	case <-p.donech:
+		return nil, errNotRunning
+	case p.dbch <- sharedDatabaseRequest{i.ID(), ch}:
+	}
+
+	db := <-ch
+	if db == nil {
+		return nil, ErrItemNotFound
+	}
+	return p.servers.Logs(db.server.params, follow)
+}
+
+func (p *sharedPool) leaseRequest(op poolLeaseOp, i Item) (Lease, error) {
+	ch := make(chan poolLeaseResult, 1)
+	select {
+	case <-p.donech:
+		return Lease{}, errNotRunning
+	case p.leasech <- poolLeaseRequest{op, i.ID(), ch}:
+		result := <-ch
+		return result.lease, result.err
+	}
+}
+
+func (p *sharedPool) sendEvent(e sharedEvent) {
+	select {
+	case <-p.donech:
+	case p.events <- e:
+	}
+}
+
+func (p *sharedPool) defaultCheckoutTimeout() time.Duration {
+	return p.config.Lifecycle.MaxDelay() + p.shared.Timeout + (500 * time.Millisecond)
+}
+
+func (p *sharedPool) monitorCtx() {
+	select {
+	case <-p.ctx.Done():
+		p.Stop()
+	case <-p.donech:
+	}
+}
+
+func (p *sharedPool) run() {
+	defer close(p.donech)
+	defer p.cancel()
+
+	if err := p.runInitialize(); err == nil {
+		p.runRunning()
+	}
+
+	// databases are removed along with their containers.
+	p.servers.Stop()
+}
+
+func (p *sharedPool) runInitialize() error {
+	defer close(p.initch)
+
+	if err := p.servers.WaitReady(); err != nil {
+		p.state = stateShutdown
+		p.initErr = err
+		return err
+	}
+
+	for i := 0; i < p.config.MinSize; i++ {
+		server, err := p.checkoutServer()
+		if err != nil {
+			p.log.WithError(err).Error("unable to check out container")
+			p.state = stateShutdown
+			p.initErr = err
+			return err
+		}
+		p.addActive(newSharedServer(server))
+	}
+
+	p.state = stateRunning
+	return nil
+}
+
+func (p *sharedPool) runRunning() {
+	ticker := time.NewTicker(poolTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.shutdownch:
+			p.state = stateShutdown
+			for _, req := range p.waiting {
+				req.ch <- sharedSlot{}
+			}
+			p.setWaiting(nil)
+			return
+
+		case req := <-p.requests:
+			p.setWaiting(append(p.waiting, req))
+			p.serveWaiters()
+
+		case <-ticker.C:
+			p.expireLeases()
+			p.serveWaiters()
+			p.scaleDown()
+
+		case req := <-p.leasech:
+			req.ch <- p.handleLeaseRequest(req)
+
+		case req := <-p.dbch:
+			req.ch <- p.databases[req.id]
+
+		case e := <-p.events:
+			p.handleEvent(e)
+		}
+	}
+}
+
+func (p *sharedPool) handleEvent(e sharedEvent) {
+	p.log.Debugf("received event: %v", e.id)
+
+	switch e.id {
+
+	case sharedEventCheckoutCancel:
+		if p.removeWaiter(e.req) {
+			return
+		}
+		// the request was answered after the client gave up.
+		select {
+		case slot := <-e.req.ch:
+			p.release(slot)
+		default:
+		}
+
+	case sharedEventCheckedOut:
+		if e.db.server.dead {
+			return
+		}
+		p.databases[e.db.ID()] = e.db
+		lease := &poolLease{
+			ttl:   p.config.LeaseTTL,
+			Lease: Lease{ID: e.db.ID(), Holder: e.holder},
+		}
+		lease.extend()
+		p.checkedOut[lease.ID] = lease
+		p.uie.ForContainer(lease.ID).EmitCheckedOut()
+		p.uie.EmitNumCheckedOut(len(p.checkedOut))
+
+	case sharedEventReady:
+		if e.db.server.dead {
+			delete(p.databases, e.db.ID())
+		} else {
+			p.databases[e.db.ID()] = e.db
+			p.uie.ForContainer(e.db.ID()).EmitReady()
+		}
+		p.release(sharedSlot{e.db.server, e.db})
+
+	case sharedEventCreateError:
+		p.release(sharedSlot{e.server, nil})
+
+	case sharedEventDropped:
+		delete(p.databases, e.db.ID())
+		p.uie.ForContainer(e.db.ID()).EmitExited()
+		p.release(sharedSlot{e.db.server, nil})
+
+	case sharedEventServer:
+		p.growing--
+		p.addActive(e.server)
+		p.serveWaiters()
+
+	case sharedEventServerError:
+		// retried on the next tick.
+		p.growing--
+
+	case sharedEventServerExit:
+		p.removeServer(e.server)
+		p.serveWaiters()
+	}
+}
+
+// release gives back a slot that was handed to a client.  A
+// database is kept for the next client; otherwise the room
+// is freed.
+func (p *sharedPool) release(slot sharedSlot) {
+	server := slot.server
+	if slot.db != nil && !server.dead {
+		server.ready = append(server.ready, slot.db)
+	} else {
+		server.databases--
+	}
+	if server.idle() {
+		server.idleSince = time.Now()
+	}
+	p.serveWaiters()
+}
+
+// serveWaiters hands out room to waiting clients, in order, and
+// adds containers if there isn't enough or there are fewer
+// than the pool's minimum size.
+func (p *sharedPool) serveWaiters() {
+	for len(p.waiting) > 0 {
+		slot, ok := p.reserve()
+		if !ok {
+			break
+		}
+		req := p.waiting[0]
+		p.setWaiting(p.waiting[1:])
+		req.ch <- slot
+	}
+
+	for len(p.active)+p.growing < p.config.MaxSize &&
+		(len(p.active)+p.growing < p.config.MinSize ||
+			len(p.waiting) > p.growing*p.shared.Capacity) {
+
+		p.log.Infof("adding container: %v -> %v (%v waiting)",
+			len(p.active)+p.growing, len(p.active)+p.growing+1, len(p.waiting))
+
+		p.growing++
+		go p.addServer()
+	}
+}
+
+// reserve returns a ready database if there is one, otherwise
+// room in the least-used container with any.
+func (p *sharedPool) reserve() (sharedSlot, bool) {
+	var best *sharedServer
+
+	for _, server := range p.active {
+		if n := len(server.ready); n > 0 {
+			db := server.ready[n-1]
+			server.ready = server.ready[:n-1]
+			return sharedSlot{server, db}, true
+		}
+		if server.databases < p.shared.Capacity &&
+			(best == nil || server.databases < best.databases) {
+			best = server
+		}
+	}
+
+	if best == nil {
+		return sharedSlot{}, false
+	}
+
+	best.databases++
+	return sharedSlot{server: best}, true
+}
+
+// scaleDown gives back containers that have had no databases
+// in use for the pool's cooldown, down to its minimum size.
+func (p *sharedPool) scaleDown() {
+	if len(p.waiting) > 0 {
+		return
+	}
+
+	cutoff := time.Now().Add(-p.config.Cooldown)
+
+	for idx := 0; idx < len(p.active) && len(p.active) > p.config.MinSize; {
+		server := p.active[idx]
+		if !server.idle() || server.idleSince.After(cutoff) {
+			idx++
+			continue
+		}
+
+		p.log.Infof("removing idle container: %v -> %v", len(p.active), len(p.active)-1)
+
+		p.active = append(p.active[:idx], p.active[idx+1:]...)
+		p.releaseServer(server)
+	}
+}
+
+// releaseServer drops the ready databases of an idle
+// container and gives it back.
+func (p *sharedPool) releaseServer(server *sharedServer) {
+	ready := server.ready
+
+	server.dead = true
+	server.ready = nil
+	close(server.releasech)
+
+	for _, db := range ready {
+		delete(p.databases, db.ID())
+		p.uie.ForContainer(db.ID()).EmitExited()
+	}
+
+	go func() {
+		for _, db := range ready {
+			p.dropDatabase(db)
+		}
+		if err := p.servers.Return(server.params); err != nil {
+			p.log.WithError(err).
+				WithField("container", server.params.Id).
+				Warn("unable to return container")
+		}
+	}()
+}
+
+func (p *sharedPool) removeWaiter(req *sharedRequest) bool {
+	for idx, other := range p.waiting {
+		if other == req {
+			p.setWaiting(append(p.waiting[:idx], p.waiting[idx+1:]...))
+			return true
+		}
+	}
+	return false
+}
+
+// addActive starts creating databases in server.
+func (p *sharedPool) addActive(server *sharedServer) {
+	p.active = append(p.active, server)
+	go p.watchServer(server)
+}
+
+// removeServer stops using a container that has exited.  Its ready
+// and checked-out databases are forgotten; clients holding one get
+// ErrItemNotFound when returning it.
+func (p *sharedPool) removeServer(server *sharedServer) {
+	if server.dead {
+		return
+	}
+
+	p.log.WithField("container", server.params.Id).Warn("container exited")
+
+	server.dead = true
+	server.ready = nil
+
+	for id, db := range p.databases {
+		if db.server != server {
+			continue
+		}
+		delete(p.databases, id)
+		delete(p.checkedOut, id)
+		p.uie.ForContainer(id).EmitExited()
+	}
+	p.uie.EmitNumCheckedOut(len(p.checkedOut))
+
+	for idx, other := range p.active {
+		if other == server {
+			p.active = append(p.active[:idx], p.active[idx+1:]...)
+			return
+		}
+	}
+}
+
+func (p *sharedPool) setWaiting(waiting []*sharedRequest) {
+	p.waiting = waiting
+	p.uie.EmitNumWaiting(len(waiting))
+}
+
+func (p *sharedPool) handleLeaseRequest(req poolLeaseRequest) poolLeaseResult {
+	db, ok := p.databases[req.id]
+	if !ok {
+		return poolLeaseResult{Lease{}, ErrItemNotFound}
+	}
+
+	lease, ok := p.checkedOut[req.id]
+	if !ok {
+		return poolLeaseResult{Lease{}, ErrNotCheckedOut}
+	}
+
+	switch req.op {
+	case leaseOpExtend:
+		lease.extend()
+	case leaseOpRelease:
+		p.log.WithField("database", db.params.Database).Info("returned")
+		delete(p.checkedOut, req.id)
+		p.uie.EmitNumCheckedOut(len(p.checkedOut))
+		p.recycle(db)
+	}
+
+	return poolLeaseResult{lease.Lease, nil}
+}
+
+// expireLeases reclaims databases whose holders have stopped
+// extending their lease.
+func (p *sharedPool) expireLeases() {
+	now := time.Now()
+	for id, lease := range p.checkedOut {
+		if !lease.expired(now) {
+			continue
+		}
+
+		p.log.WithField("database", id).
+			WithField("holder", lease.Holder).
+			WithField("expires", lease.Expires).
+			Warn("lease expired")
+
+		p.uie.ForContainer(id).EmitLeaseExpired(lease.Holder)
+
+		delete(p.checkedOut, id)
+		p.uie.EmitNumCheckedOut(len(p.checkedOut))
+		p.recycle(p.databases[id])
+	}
+}
+
+// addServer checks out another container to create databases in.
+func (p *sharedPool) addServer() {
+	server, err := p.checkoutServer()
+	if err != nil {
+		p.log.WithError(err).Warn("unable to check out container")
+		p.sendEvent(sharedEvent{id: sharedEventServerError})
+		return
+	}
+	p.sendEvent(sharedEvent{id: sharedEventServer, server: newSharedServer(server)})
+}
+
+// watchServer reports the exit of server's container until
+// it is given back.
+func (p *sharedPool) watchServer(server *sharedServer) {
+	item, err := p.servers.lookupItem(server.params)
+	switch err {
+	case nil:
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-server.releasech:
+			return
+		case <-item.done():
+		}
+	case ErrItemNotFound:
+	default:
+		return
+	}
+	p.sendEvent(sharedEvent{id: sharedEventServerExit, server: server})
+}
+
+// checkoutServer waits for a container.  It may have to be started
+// and pass its healthcheck and initialize actions first.
+func (p *sharedPool) checkoutServer() (params.Params, error) {
+	lc := p.config.Lifecycle
+	timeout := containerStartTimeout + lc.StartDelay() + lc.MaxDelay() + (500 * time.Millisecond)
+	return p.servers.CheckoutWith(p.ctx, WithTimeout(timeout), WithHolder(sharedHolder))
+}
+
+// createDatabase creates a database in server.
+func (p *sharedPool) createDatabase(server *sharedServer) (*sharedDatabase, error) {
+	name, err := sharedDatabaseName()
+	if err != nil {
+		p.sendEvent(sharedEvent{id: sharedEventCreateError, server: server})
+		return nil, err
+	}
+
+	log := p.log.WithField("database", name).WithField("container", server.params.Id)
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.shared.Timeout)
+	defer cancel()
+
+	result, err := p.shared.Provider.Create(lifecycle.NewEnv(ctx, log), server.params, name)
+	if err != nil {
+		log.WithError(err).Warn("unable to create database")
+		p.sendEvent(sharedEvent{id: sharedEventCreateError, server: server})
+		return nil, err
+	}
+
+	result.Id = server.params.Id + "-" + name
+
+	p.uie.ForContainer(result.Id).EmitCreated()
+
+	return &sharedDatabase{server: server, params: result}, nil
+}
+
+// recycle resets a returned database for reuse, or drops it.
+func (p *sharedPool) recycle(db *sharedDatabase) {
+	p.uie.ForContainer(db.ID()).EmitResetting()
+	go func() {
+		if p.resetDatabase(db) {
+			p.sendEvent(sharedEvent{id: sharedEventReady, db: db})
+			return
+		}
+		p.dropDatabase(db)
+		p.sendEvent(sharedEvent{id: sharedEventDropped, db: db})
+	}()
+}
+
+// resetDatabase runs the reset action against the database.  false
+// is returned if there is no reset action or it fails.
+func (p *sharedPool) resetDatabase(db *sharedDatabase) bool {
+	item, err := p.servers.lookupItem(db.server.params)
+	if err != nil {
+		return false
+	}
+
+	actions := item.actions()
+	if actions == nil || !actions.HasReset() {
+		return false
+	}
+
+	if err := actions.DoReset(p.ctx, db.params); err != nil {
+		p.log.WithError(err).WithField("database", db.params.Database).Warn("reset failed")
+		return false
+	}
+	return true
+}
+
+func (p *sharedPool) dropDatabase(db *sharedDatabase) {
+	log := p.log.WithField("database", db.params.Database).WithField("container", db.server.params.Id)
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.shared.Timeout)
+	defer cancel()
+
+	if err := p.shared.Provider.Drop(lifecycle.NewEnv(ctx, log), db.server.params, db.params); err != nil {
+		log.WithError(err).Warn("unable to drop database")
+	}
+}
+
+func sharedDatabaseName() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return sharedDatabasePrefix + hex.EncodeToString(buf), nil
+}
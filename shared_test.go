@@ -0,0 +1,392 @@
+package ephemerald
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/boz/ephemerald/config"
+	"github.com/boz/ephemerald/lifecycle"
+	"github.com/boz/ephemerald/params"
+	"github.com/boz/ephemerald/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedPool_capacity(t *testing.T) {
+	provider, pool := startTestSharedPool(t, `"min":1,"max":2,"shared":{"type":"test.shared","capacity":2}`)
+	defer pool.Stop()
+
+	var dbs []params.Params
+	for i := 0; i < 4; i++ {
+		p, err := pool.Checkout()
+		require.NoError(t, err)
+		dbs = append(dbs, p)
+	}
+
+	servers := map[string]int{}
+	names := map[string]bool{}
+	for _, p := range dbs {
+		assert.True(t, strings.HasPrefix(p.Database, sharedDatabasePrefix), p.Database)
+		assert.True(t, strings.HasSuffix(p.Id, "-"+p.Database), p.Id)
+		servers[p.Hostname]++
+		names[p.Database] = true
+	}
+	assert.Equal(t, map[string]int{"server-1": 2, "server-2": 2}, servers)
+	assert.Len(t, names, 4)
+
+	// full.
+	_, err := pool.Checkout(WithTimeout(50 * time.Millisecond))
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	lease, err := pool.Heartbeat(dbs[0])
+	require.NoError(t, err)
+	assert.Equal(t, dbs[0].Id, lease.ID)
+
+	logs, err := pool.Logs(dbs[0], false)
+	require.NoError(t, err)
+	buf, err := ioutil.ReadAll(logs)
+	require.NoError(t, err)
+	assert.Equal(t, dbs[0].Hostname, string(buf))
+
+	require.NoError(t, pool.Return(dbs[0]))
+
+	p, err := pool.Checkout()
+	require.NoError(t, err)
+	assert.Equal(t, dbs[0].Hostname, p.Hostname)
+	assert.NotEqual(t, dbs[0].Database, p.Database)
+
+	assert.Equal(t, []string{dbs[0].Database}, provider.getDropped())
+}
+
+func TestSharedPool_waiting(t *testing.T) {
+	_, pool := startTestSharedPool(t, `"size":1,"shared":{"type":"test.shared","capacity":1}`)
+	defer pool.Stop()
+
+	first, err := pool.Checkout()
+	require.NoError(t, err)
+
+	ch := make(chan params.Params, 1)
+	go func() {
+		p, err := pool.CheckoutWith(context.Background())
+		assert.NoError(t, err)
+		ch <- p
+	}()
+
+	select {
+	case <-ch:
+		require.Fail(t, "checked out past capacity")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, pool.Return(first))
+	assert.Error(t, pool.Return(first))
+
+	select {
+	case p := <-ch:
+		assert.NotEqual(t, first.Database, p.Database)
+	case <-time.After(time.Second):
+		require.Fail(t, "timed out waiting for checkout")
+	}
+}
+
+func TestSharedPool_stop(t *testing.T) {
+	_, pool := startTestSharedPool(t, `"size":1,"shared":{"type":"test.shared","capacity":1}`)
+
+	_, err := pool.Checkout()
+	require.NoError(t, err)
+
+	ch := make(chan error, 1)
+	go func() {
+		_, err := pool.CheckoutWith(context.Background())
+		ch <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, pool.Stop())
+
+	select {
+	case err := <-ch:
+		assert.Equal(t, errNotRunning, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "timed out waiting for checkout")
+	}
+}
+
+func TestSharedPool_serverExit(t *testing.T) {
+	servers := &testServerPool{reset: true}
+	_, pool := startTestSharedPoolWith(t, `"size":1,"shared":{"type":"test.shared","capacity":2}`, servers)
+	defer pool.Stop()
+
+	first, err := pool.Checkout()
+	require.NoError(t, err)
+	second, err := pool.Checkout()
+	require.NoError(t, err)
+
+	// reset and kept for the next client.
+	require.NoError(t, pool.Return(second))
+
+	servers.kill(first.Hostname)
+
+	deadline := time.Now().Add(time.Second)
+	for err == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		_, err = pool.Heartbeat(first)
+	}
+	assert.Equal(t, ErrItemNotFound, err)
+	assert.Equal(t, ErrItemNotFound, pool.Return(first))
+
+	// replaced with another container.
+	for i := 0; i < 2; i++ {
+		p, err := pool.Checkout()
+		require.NoError(t, err)
+		assert.Equal(t, "server-2", p.Hostname)
+		assert.NotEqual(t, second.Database, p.Database)
+	}
+}
+
+func TestSharedPool_scaleDown(t *testing.T) {
+	servers := &testServerPool{reset: true}
+	provider, pool := startTestSharedPoolWith(t, `"min":0,"max":1,"cooldown":"10ms","shared":{"type":"test.shared"}`, servers)
+	defer pool.Stop()
+
+	p, err := pool.Checkout()
+	require.NoError(t, err)
+	assert.Equal(t, "server-1", p.Hostname)
+
+	// in use.
+	time.Sleep(poolTickInterval + 100*time.Millisecond)
+	assert.Empty(t, servers.getReturned())
+
+	// reset and kept until the container is given back.
+	require.NoError(t, pool.Return(p))
+
+	deadline := time.Now().Add(3 * poolTickInterval)
+	for len(servers.getReturned()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, []string{"server-1"}, servers.getReturned())
+	assert.Equal(t, []string{p.Database}, provider.getDropped())
+	assert.Equal(t, ErrItemNotFound, pool.Return(p))
+
+	p, err = pool.Checkout()
+	require.NoError(t, err)
+	assert.Equal(t, "server-2", p.Hostname)
+}
+
+func TestSharedPool_serverTimeout(t *testing.T) {
+	servers := &testServerPool{}
+	_, pool := startTestSharedPoolWith(t, `"size":1,"shared":{"type":"test.shared"},
+		"actions":{"healthcheck":{"type":"noop","retries":0,"timeout":"2s","delay":"0s"}}`, servers)
+	defer pool.Stop()
+
+	// container start, healthcheck and reset allowances.
+	assert.Equal(t, []time.Duration{containerStartTimeout + 4*time.Second + 500*time.Millisecond}, servers.timeouts)
+}
+
+func TestSharedPool_emit(t *testing.T) {
+	uie := &testEmitter{PoolEmitter: ui.NewNoopEmitter().ForPool("")}
+	_, pool := startTestSharedPoolWithEmitter(t, `"size":1,"shared":{"type":"test.shared","capacity":1}`, &testServerPool{}, uie)
+	defer pool.Stop()
+
+	p, err := pool.Checkout()
+	require.NoError(t, err)
+	require.NoError(t, pool.Return(p))
+
+	// dropped; there is no reset action.
+	_, err = pool.Checkout()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		p.Id + ": created",
+		p.Id + ": checked-out",
+		"checked-out: 1",
+		"checked-out: 0",
+		p.Id + ": resetting",
+		p.Id + ": exited",
+	}, uie.get()[:6])
+}
+
+func startTestSharedPool(t *testing.T, opts string) (*testDatabaseProvider, Pool) {
+	return startTestSharedPoolWith(t, opts, &testServerPool{})
+}
+
+func startTestSharedPoolWith(t *testing.T, opts string, servers *testServerPool) (*testDatabaseProvider, Pool) {
+	return startTestSharedPoolWithEmitter(t, opts, servers, ui.NewNoopEmitter())
+}
+
+func startTestSharedPoolWithEmitter(t *testing.T, opts string, servers *testServerPool, uie ui.Emitter) (*testDatabaseProvider, Pool) {
+	buf := []byte(`{"image":"postgres","port":5432,` + opts + `}`)
+
+	cfg, err := config.Parse(logrus.New(), uie, t.Name(), buf)
+	require.NoError(t, err)
+
+	provider := cfg.Shared.Provider.(*testDatabaseProvider)
+	pool := startSharedPool(context.Background(), cfg, servers)
+	require.NoError(t, pool.WaitReady())
+
+	return provider, pool
+}
+
+func init() {
+	lifecycle.MakeDatabaseProviderPlugin("test.shared", func([]byte) (lifecycle.DatabaseProvider, error) {
+		return &testDatabaseProvider{}, nil
+	})
+}
+
+// testServerPool hands out servers whose hostname is their ID and
+// records those given back.  Databases are reset for reuse if reset
+// is set.
+type testServerPool struct {
+	reset    bool
+	count    int
+	timeouts []time.Duration
+	live     map[string]chan bool
+	returned []string
+	mtx      sync.Mutex
+}
+
+func (s *testServerPool) CheckoutWith(_ context.Context, opts ...CheckoutOption) (params.Params, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.timeouts = append(s.timeouts, newCheckoutOptions(opts).timeout)
+	if s.live == nil {
+		s.live = make(map[string]chan bool)
+	}
+	s.count++
+	id := fmt.Sprintf("server-%v", s.count)
+	s.live[id] = make(chan bool)
+	return params.Params{Id: id, Hostname: id}, nil
+}
+
+func (s *testServerPool) Return(i Item) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.returned = append(s.returned, i.ID())
+	return nil
+}
+
+func (s *testServerPool) getReturned() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]string(nil), s.returned...)
+}
+
+func (s *testServerPool) lookupItem(i Item) (poolItem, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	donech, ok := s.live[i.ID()]
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+	return testServerItem{testItem(i.ID()), donech, s.reset}, nil
+}
+
+func (s *testServerPool) kill(id string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	close(s.live[id])
+	delete(s.live, id)
+}
+
+func (s *testServerPool) Logs(i Item, _ bool) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(i.ID())), nil
+}
+
+func (s *testServerPool) Stop() error      { return nil }
+func (s *testServerPool) WaitReady() error { return nil }
+
+type testServerItem struct {
+	testItem
+	donech   chan bool
+	canReset bool
+}
+
+func (i testServerItem) done() <-chan bool { return i.donech }
+
+func (i testServerItem) actions() lifecycle.ContainerManager {
+	if i.canReset {
+		return testResetActions{}
+	}
+	return nil
+}
+
+// testResetActions succeeds in resetting every database.
+type testResetActions struct {
+	lifecycle.ContainerManager
+}
+
+func (testResetActions) HasReset() bool                               { return true }
+func (testResetActions) DoReset(context.Context, params.Params) error { return nil }
+
+type testDatabaseProvider struct {
+	dropped []string
+	mtx     sync.Mutex
+}
+
+func (dp *testDatabaseProvider) Create(_ lifecycle.Env, server params.Params, name string) (params.Params, error) {
+	server.Database = name
+	return server, nil
+}
+
+func (dp *testDatabaseProvider) Drop(_ lifecycle.Env, _ params.Params, db params.Params) error {
+	dp.mtx.Lock()
+	defer dp.mtx.Unlock()
+	dp.dropped = append(dp.dropped, db.Database)
+	return nil
+}
+
+func (dp *testDatabaseProvider) getDropped() []string {
+	dp.mtx.Lock()
+	defer dp.mtx.Unlock()
+	return append([]string(nil), dp.dropped...)
+}
+
+// testEmitter records the database and checkout events of a pool.
+type testEmitter struct {
+	ui.PoolEmitter
+	events []string
+	mtx    sync.Mutex
+}
+
+func (e *testEmitter) ForPool(string) ui.PoolEmitter { return e }
+
+func (e *testEmitter) ForContainer(id string) ui.ContainerEmitter {
+	return &testContainerEmitter{ui.NewNoopEmitter().ForPool("").ForContainer(id), e, id}
+}
+
+func (e *testEmitter) EmitNumWaiting(int) {}
+
+func (e *testEmitter) EmitNumCheckedOut(n int) {
+	e.add(fmt.Sprintf("checked-out: %v", n))
+}
+
+func (e *testEmitter) add(event string) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.events = append(e.events, event)
+}
+
+func (e *testEmitter) get() []string {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return append([]string(nil), e.events...)
+}
+
+type testContainerEmitter struct {
+	ui.ContainerEmitter
+	parent *testEmitter
+	id     string
+}
+
+func (e *testContainerEmitter) EmitCreated()    { e.parent.add(e.id + ": created") }
+func (e *testContainerEmitter) EmitCheckedOut() { e.parent.add(e.id + ": checked-out") }
+func (e *testContainerEmitter) EmitResetting()  { e.parent.add(e.id + ": resetting") }
+func (e *testContainerEmitter) EmitReady()      { e.parent.add(e.id + ": ready") }
+func (e *testContainerEmitter) EmitExited()     { e.parent.add(e.id + ": exited") }
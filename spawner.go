@@ -2,7 +2,6 @@ package ephemerald
 
 import (
 	"github.com/Sirupsen/logrus"
-	"github.com/boz/ephemerald/lifecycle"
 	"github.com/boz/ephemerald/ui"
 )
 
@@ -12,12 +11,18 @@ type poolItemSpawner interface {
 	request(int)
 }
 
+// creates a single pool item.
+type spawnFunc func() (poolItem, error)
+
 type pispawner struct {
-	adapter   dockerAdapter
-	lifecycle lifecycle.Manager
+	create spawnFunc
 
+	// number of items being created
 	pending int
-	needed  int
+
+	// number of items the pool has asked for that
+	// it has not yet received.
+	needed int
 
 	// close to commence shutdown
 	shutdownch chan bool
@@ -31,6 +36,9 @@ type pispawner struct {
 	// where to send new children
 	nextch chan poolItem
 
+	// children waiting to be sent
+	created []poolItem
+
 	log logrus.FieldLogger
 
 	uie ui.PoolEmitter
@@ -41,14 +49,13 @@ type spawnresult struct {
 	err  error
 }
 
-func newPoolItemSpawner(uie ui.PoolEmitter, adapter dockerAdapter, lifecycle lifecycle.Manager) poolItemSpawner {
+func newPoolItemSpawner(uie ui.PoolEmitter, log logrus.FieldLogger, create spawnFunc) poolItemSpawner {
 	s := &pispawner{
-		adapter:   adapter,
-		lifecycle: lifecycle,
-		requestch: make(chan int, 5),
+		create:    create,
+		requestch: make(chan int),
 		resultch:  make(chan spawnresult),
 		nextch:    make(chan poolItem),
-		log:       adapter.logger().WithField("component", "spawner"),
+		log:       log.WithField("component", "spawner"),
 		uie:       uie,
 	}
 
@@ -65,6 +72,9 @@ func (s *pispawner) next() <-chan poolItem {
 	return s.nextch
 }
 
+// request sets the number of items that the pool needs in addition
+// to the ones it has already received.  Items that are being created
+// or are waiting to be sent count towards the request.
 func (s *pispawner) request(count int) {
 	s.requestch <- count
 }
@@ -80,6 +90,8 @@ func (s *pispawner) fillRequests() {
 
 		s.uie.EmitNumPending(s.pending)
 
+		next, nextch := s.nextCreated()
+
 		select {
 		case request, ok := <-s.requestch:
 			if !ok {
@@ -95,11 +107,13 @@ func (s *pispawner) fillRequests() {
 				continue
 			}
 
+			s.created = append(s.created, result.item)
+
+		case nextch <- next:
+			s.created = s.created[1:]
 			if s.needed > 0 {
 				s.needed--
 			}
-
-			s.nextch <- result.item
 		}
 	}
 }
@@ -110,10 +124,12 @@ func (s *pispawner) drain() {
 	for {
 		s.uie.EmitNumPending(s.pending)
 
-		if s.pending <= 0 {
+		if s.pending <= 0 && len(s.created) == 0 {
 			return
 		}
 
+		next, nextch := s.nextCreated()
+
 		select {
 		case result := <-s.resultch:
 			s.pending--
@@ -122,15 +138,28 @@ func (s *pispawner) drain() {
 				s.log.WithError(result.err).Error("can't create child")
 				continue
 			}
-			s.nextch <- result.item
+			s.created = append(s.created, result.item)
+
+		case nextch <- next:
+			s.created = s.created[1:]
 		}
 	}
 }
 
+// nextCreated returns the next child to send and the channel to send it on.
+// the channel is nil when there are no children waiting so that
+// requests are never blocked on the pool receiving new children.
+func (s *pispawner) nextCreated() (poolItem, chan poolItem) {
+	if len(s.created) == 0 {
+		return nil, nil
+	}
+	return s.created[0], s.nextch
+}
+
 func (s *pispawner) fill() {
-	for ; s.pending < s.needed; s.pending++ {
+	for ; s.pending+len(s.created) < s.needed; s.pending++ {
 		go func() {
-			item, err := createPoolItem(s.uie, s.log, s.adapter, s.lifecycle)
+			item, err := s.create()
 			s.resultch <- spawnresult{item, err}
 		}()
 	}
@@ -1,6 +1,9 @@
 package testutil
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"path"
 	"strings"
@@ -9,6 +12,7 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/boz/ephemerald"
 	"github.com/boz/ephemerald/config"
+	"github.com/boz/ephemerald/lifecycle"
 	"github.com/boz/ephemerald/params"
 	"github.com/boz/ephemerald/ui"
 	"github.com/ghodss/yaml"
@@ -38,6 +42,34 @@ func ContainerEmitter() ui.ContainerEmitter {
 	return PoolEmitter().ForContainer(CID())
 }
 
+// Container returns a lifecycle.Container that doesn't
+// support any operations.
+func Container() lifecycle.Container {
+	return container{}
+}
+
+type container struct{}
+
+func (container) ID() string {
+	return CID()
+}
+
+func (container) Exec(context.Context, []string, []string, io.Writer, io.Writer) (int, error) {
+	return 0, fmt.Errorf("exec not supported")
+}
+
+func (container) CopyTo(context.Context, string, io.Reader) error {
+	return fmt.Errorf("copy not supported")
+}
+
+func (container) Health(context.Context) (lifecycle.Health, error) {
+	return lifecycle.Health{Status: lifecycle.HealthNone}, nil
+}
+
+func (container) Logs(context.Context) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader("")), nil
+}
+
 func RunPoolFromFile(t *testing.T, path string, fn func(params.Params)) {
 	WithPoolFromFile(t, path, func(pool ephemerald.Pool) {
 		item, err := pool.Checkout()
@@ -1,5 +1,10 @@
 package ui
 
+import (
+	"fmt"
+	"time"
+)
+
 type Emitter interface {
 	ForPool(name string) PoolEmitter
 }
@@ -16,6 +21,9 @@ type PoolEmitter interface {
 	EmitNumItems(int)
 	EmitNumPending(int)
 	EmitNumReady(int)
+	EmitTargetSize(int)
+	EmitNumWaiting(int)
+	EmitNumCheckedOut(int)
 }
 
 type ContainerEmitter interface {
@@ -23,12 +31,16 @@ type ContainerEmitter interface {
 	EmitStarted()
 	EmitLive()
 	EmitReady()
+	EmitCheckedOut()
 	EmitResetting()
 	EmitExiting()
 	EmitExited()
+	EmitLeaseExpired(string)
 
 	EmitActionAttempt(string, string, int, int)
-	EmitActionResult(string, string, int, int, error)
+	EmitActionResult(string, string, int, int, time.Duration, error)
+	EmitActionStep(string, string, string)
+	EmitLifecycleFailed(string, error)
 }
 
 func newEmitter(processor *processor) Emitter {
@@ -82,6 +94,18 @@ func (e *processorPoolEmitter) EmitNumReady(count int) {
 	e.sendEvent(pevent{peventNumReady, e.poolName, nil, count})
 }
 
+func (e *processorPoolEmitter) EmitTargetSize(count int) {
+	e.sendEvent(pevent{peventTargetSize, e.poolName, nil, count})
+}
+
+func (e *processorPoolEmitter) EmitNumWaiting(count int) {
+	e.sendEvent(pevent{peventNumWaiting, e.poolName, nil, count})
+}
+
+func (e *processorPoolEmitter) EmitNumCheckedOut(count int) {
+	e.sendEvent(pevent{peventCheckedOut, e.poolName, nil, count})
+}
+
 func (e *processorPoolEmitter) sendEvent(event pevent) {
 	e.processor.sendPoolEvent(event)
 }
@@ -95,33 +119,46 @@ func (e *processorPoolEmitter) ForContainer(containerId string) ContainerEmitter
 }
 
 func (e *processorContainerEmitter) EmitCreated() {
-	e.sendEvent(cevent{ceventCreated, e.containerId, e.poolName, "", "", 0, 0, nil})
+	e.sendEvent(cevent{ceventCreated, e.containerId, e.poolName, "", "", 0, 0, 0, "", nil})
 }
 func (e *processorContainerEmitter) EmitStarted() {
-	e.sendEvent(cevent{ceventStarted, e.containerId, e.poolName, "", "", 0, 0, nil})
+	e.sendEvent(cevent{ceventStarted, e.containerId, e.poolName, "", "", 0, 0, 0, "", nil})
 }
 func (e *processorContainerEmitter) EmitLive() {
-	e.sendEvent(cevent{ceventLive, e.containerId, e.poolName, "", "", 0, 0, nil})
+	e.sendEvent(cevent{ceventLive, e.containerId, e.poolName, "", "", 0, 0, 0, "", nil})
 }
 func (e *processorContainerEmitter) EmitReady() {
-	e.sendEvent(cevent{ceventReady, e.containerId, e.poolName, "", "", 0, 0, nil})
+	e.sendEvent(cevent{ceventReady, e.containerId, e.poolName, "", "", 0, 0, 0, "", nil})
+}
+func (e *processorContainerEmitter) EmitCheckedOut() {
+	e.sendEvent(cevent{ceventCheckedOut, e.containerId, e.poolName, "", "", 0, 0, 0, "", nil})
 }
 func (e *processorContainerEmitter) EmitResetting() {
-	e.sendEvent(cevent{ceventResetting, e.containerId, e.poolName, "", "", 0, 0, nil})
+	e.sendEvent(cevent{ceventResetting, e.containerId, e.poolName, "", "", 0, 0, 0, "", nil})
 }
 func (e *processorContainerEmitter) EmitExiting() {
-	e.sendEvent(cevent{ceventExiting, e.containerId, e.poolName, "", "", 0, 0, nil})
+	e.sendEvent(cevent{ceventExiting, e.containerId, e.poolName, "", "", 0, 0, 0, "", nil})
 }
 func (e *processorContainerEmitter) EmitExited() {
-	e.sendEvent(cevent{ceventExited, e.containerId, e.poolName, "", "", 0, 0, nil})
+	e.sendEvent(cevent{ceventExited, e.containerId, e.poolName, "", "", 0, 0, 0, "", nil})
+}
+func (e *processorContainerEmitter) EmitLeaseExpired(holder string) {
+	err := fmt.Errorf("lease expired (holder: %v)", holder)
+	e.sendEvent(cevent{ceventLeaseExpired, e.containerId, e.poolName, "", "", 0, 0, 0, "", err})
 }
 func (e *processorContainerEmitter) EmitActionAttempt(lname string,
 	name string, attempt int, attempts int) {
-	e.sendEvent(cevent{ceventAction, e.containerId, e.poolName, lname, name, attempt, attempts, nil})
+	e.sendEvent(cevent{ceventAction, e.containerId, e.poolName, lname, name, attempt, attempts, 0, "", nil})
 }
 func (e *processorContainerEmitter) EmitActionResult(lname string,
-	name string, attempt int, attempts int, err error) {
-	e.sendEvent(cevent{ceventResult, e.containerId, e.poolName, lname, name, attempt, attempts, err})
+	name string, attempt int, attempts int, delay time.Duration, err error) {
+	e.sendEvent(cevent{ceventResult, e.containerId, e.poolName, lname, name, attempt, attempts, delay, "", err})
+}
+func (e *processorContainerEmitter) EmitActionStep(lname string, name string, step string) {
+	e.sendEvent(cevent{ceventActionStep, e.containerId, e.poolName, lname, name, 0, 0, 0, step, nil})
+}
+func (e *processorContainerEmitter) EmitLifecycleFailed(lname string, err error) {
+	e.sendEvent(cevent{ceventLifecycleFailed, e.containerId, e.poolName, lname, "", 0, 0, 0, "", err})
 }
 func (e *processorContainerEmitter) sendEvent(evt cevent) {
 	e.processor.sendContainerEvent(evt)
@@ -1,15 +1,19 @@
 package ui
 
+import "time"
+
+// NewNoopUI creates a UI that doesn't display anything.
+// The status of pools is still tracked.
 func NewNoopUI() UI {
-	return noopUI{}
+	return newProcessedUI(noopWriter{})
 }
 
-type noopUI struct{}
+type noopWriter struct{}
 
-func (noopUI) Emitter() Emitter {
-	return NewNoopEmitter()
-}
-func (noopUI) Stop() {}
+func (noopWriter) updatePool(pool)           {}
+func (noopWriter) updateContainer(container) {}
+func (noopWriter) deleteContainer(container) {}
+func (noopWriter) stop()                     {}
 
 type noopEmitter struct{}
 
@@ -28,13 +32,20 @@ func (e noopEmitter) EmitDone()                              {}
 func (e noopEmitter) EmitNumItems(int)                       {}
 func (e noopEmitter) EmitNumPending(int)                     {}
 func (e noopEmitter) EmitNumReady(int)                       {}
-
-func (e noopEmitter) EmitCreated()                                     {}
-func (e noopEmitter) EmitStarted()                                     {}
-func (e noopEmitter) EmitLive()                                        {}
-func (e noopEmitter) EmitReady()                                       {}
-func (e noopEmitter) EmitResetting()                                   {}
-func (e noopEmitter) EmitExiting()                                     {}
-func (e noopEmitter) EmitExited()                                      {}
-func (e noopEmitter) EmitActionAttempt(string, string, int, int)       {}
-func (e noopEmitter) EmitActionResult(string, string, int, int, error) {}
+func (e noopEmitter) EmitTargetSize(int)                     {}
+func (e noopEmitter) EmitNumWaiting(int)                     {}
+func (e noopEmitter) EmitNumCheckedOut(int)                  {}
+
+func (e noopEmitter) EmitCreated()                                                    {}
+func (e noopEmitter) EmitStarted()                                                    {}
+func (e noopEmitter) EmitLive()                                                       {}
+func (e noopEmitter) EmitReady()                                                      {}
+func (e noopEmitter) EmitCheckedOut()                                                 {}
+func (e noopEmitter) EmitResetting()                                                  {}
+func (e noopEmitter) EmitExiting()                                                    {}
+func (e noopEmitter) EmitExited()                                                     {}
+func (e noopEmitter) EmitLeaseExpired(string)                                         {}
+func (e noopEmitter) EmitActionAttempt(string, string, int, int)                      {}
+func (e noopEmitter) EmitActionResult(string, string, int, int, time.Duration, error) {}
+func (e noopEmitter) EmitActionStep(string, string, string)                           {}
+func (e noopEmitter) EmitLifecycleFailed(string, error)                               {}
@@ -1,5 +1,7 @@
 package ui
 
+import "time"
+
 type peventId string
 
 const (
@@ -11,6 +13,9 @@ const (
 	peventNumItems   peventId = "num-items"
 	peventNumPending peventId = "num-pending"
 	peventNumReady   peventId = "num-ready"
+	peventTargetSize peventId = "target-size"
+	peventNumWaiting peventId = "num-waiting"
+	peventCheckedOut peventId = "num-checked-out"
 )
 
 type pevent struct {
@@ -23,15 +28,20 @@ type pevent struct {
 type ceventId string
 
 const (
-	ceventCreated   ceventId = "created"
-	ceventStarted   ceventId = "started"
-	ceventLive      ceventId = "live"
-	ceventReady     ceventId = "ready"
-	ceventResetting ceventId = "resetting"
-	ceventExiting   ceventId = "exiting"
-	ceventExited    ceventId = "exited"
-	ceventAction    ceventId = "action-attempt"
-	ceventResult    ceventId = "action-result"
+	ceventCreated      ceventId = "created"
+	ceventStarted      ceventId = "started"
+	ceventLive         ceventId = "live"
+	ceventReady        ceventId = "ready"
+	ceventCheckedOut   ceventId = "checked-out"
+	ceventResetting    ceventId = "resetting"
+	ceventExiting      ceventId = "exiting"
+	ceventExited       ceventId = "exited"
+	ceventLeaseExpired ceventId = "lease-expired"
+	ceventAction       ceventId = "action-attempt"
+	ceventResult       ceventId = "action-result"
+	ceventActionStep   ceventId = "action-step"
+
+	ceventLifecycleFailed ceventId = "lifecycle-failed"
 )
 
 const (
@@ -48,6 +58,12 @@ type cevent struct {
 	actionAttempt  int
 	actionAttempts int
 
+	// delay before the next attempt
+	actionDelay time.Duration
+
+	// progress within an action
+	actionStep string
+
 	err error
 }
 
@@ -147,6 +163,9 @@ func (p *processor) handleContainerUpdate(c *container, e cevent) {
 	case ceventReady:
 		c.state = cstateReady
 		reset = true
+	case ceventCheckedOut:
+		c.state = cstateCheckedOut
+		reset = true
 	case ceventResetting:
 		c.state = cstateResetting
 		reset = true
@@ -155,16 +174,31 @@ func (p *processor) handleContainerUpdate(c *container, e cevent) {
 	case ceventExited:
 		c.state = cstateExited
 		exited = true
+	case ceventLeaseExpired:
+		c.state = cstateExpired
+		c.lifecycleName = ""
+		c.actionName = ""
+		c.actionError = e.err
 	case ceventAction:
 		c.lifecycleName = e.lifecycleName
 		c.actionName = e.actionName
 		c.actionAttempt = e.actionAttempt
 		c.actionAttempts = e.actionAttempts
+		c.actionDelay = 0
+		c.actionStep = ""
 	case ceventResult:
 		c.lifecycleName = e.lifecycleName
 		c.actionName = e.actionName
 		c.actionAttempt = e.actionAttempt
 		c.actionAttempts = e.actionAttempts
+		c.actionDelay = e.actionDelay
+		c.actionError = e.err
+	case ceventActionStep:
+		c.lifecycleName = e.lifecycleName
+		c.actionName = e.actionName
+		c.actionStep = e.actionStep
+	case ceventLifecycleFailed:
+		c.lifecycleName = e.lifecycleName
 		c.actionError = e.err
 	}
 
@@ -173,6 +207,8 @@ func (p *processor) handleContainerUpdate(c *container, e cevent) {
 		c.actionName = ""
 		c.actionAttempt = 0
 		c.actionAttempts = 0
+		c.actionDelay = 0
+		c.actionStep = ""
 		c.actionError = nil
 	}
 
@@ -214,6 +250,12 @@ func (p *processor) handlePoolUpdate(pool *pool, e pevent) {
 		pool.numPending = e.count
 	case peventNumReady:
 		pool.numReady = e.count
+	case peventTargetSize:
+		pool.targetSize = e.count
+	case peventNumWaiting:
+		pool.numWaiting = e.count
+	case peventCheckedOut:
+		pool.numOut = e.count
 	}
 
 	p.writer.updatePool(*pool)
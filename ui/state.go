@@ -1,5 +1,7 @@
 package ui
 
+import "time"
+
 type pstate string
 
 const (
@@ -15,15 +17,17 @@ const (
 type cstate string
 
 const (
-	cstateCreated   cstate = "created"
-	cstateStarted   cstate = "started"
-	cstateLive      cstate = "live"
-	cstateReady     cstate = "ready"
-	cstateResetting cstate = "resetting"
-	cstateExiting   cstate = "exiting"
-	cstateExited    cstate = "exited"
-
-	cstateMaxLen = len(cstateResetting)
+	cstateCreated    cstate = "created"
+	cstateStarted    cstate = "started"
+	cstateLive       cstate = "live"
+	cstateReady      cstate = "ready"
+	cstateCheckedOut cstate = "checked-out"
+	cstateResetting  cstate = "resetting"
+	cstateExiting    cstate = "exiting"
+	cstateExited     cstate = "exited"
+	cstateExpired    cstate = "expired"
+
+	cstateMaxLen = len(cstateCheckedOut)
 )
 
 type pool struct {
@@ -33,6 +37,9 @@ type pool struct {
 	numItems   int
 	numPending int
 	numReady   int
+	targetSize int
+	numWaiting int
+	numOut     int
 
 	err error
 }
@@ -47,5 +54,7 @@ type container struct {
 	actionName     string
 	actionAttempt  int
 	actionAttempts int
+	actionDelay    time.Duration
+	actionStep     string
 	actionError    error
 }
@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Status provides a snapshot of the pools and their items.
+type Status interface {
+	Pools() []PoolStatus
+	Pool(name string) (PoolStatus, bool)
+	Items(pool string) ([]ItemStatus, bool)
+}
+
+type PoolStatus struct {
+	Name       string `json:"name"`
+	State      string `json:"state"`
+	NumItems   int    `json:"total"`
+	NumPending int    `json:"pending"`
+	NumReady   int    `json:"ready"`
+	NumOut     int    `json:"checked-out"`
+	NumWaiting int    `json:"waiting"`
+	TargetSize int    `json:"target"`
+	Error      string `json:"error,omitempty"`
+}
+
+type ItemStatus struct {
+	ID        string `json:"id"`
+	Pool      string `json:"pool"`
+	State     string `json:"state"`
+	Lifecycle string `json:"lifecycle,omitempty"`
+	Action    string `json:"action,omitempty"`
+	Attempt   int    `json:"attempt,omitempty"`
+	Attempts  int    `json:"attempts,omitempty"`
+	Step      string `json:"step,omitempty"`
+	RetryIn   string `json:"retry-in,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// statusWriter keeps the latest state of every pool and
+// container for Status queries.
+type statusWriter struct {
+	pools      map[string]pool
+	containers map[string]container
+	mtx        sync.RWMutex
+}
+
+func newStatusWriter() *statusWriter {
+	return &statusWriter{
+		pools:      make(map[string]pool),
+		containers: make(map[string]container),
+	}
+}
+
+func (w *statusWriter) updatePool(p pool) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.pools[p.name] = p
+}
+
+func (w *statusWriter) updateContainer(c container) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.containers[c.id] = c
+}
+
+func (w *statusWriter) deleteContainer(c container) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	delete(w.containers, c.id)
+}
+
+func (w *statusWriter) stop() {
+}
+
+func (w *statusWriter) Pools() []PoolStatus {
+	w.mtx.RLock()
+	defer w.mtx.RUnlock()
+
+	result := make([]PoolStatus, 0, len(w.pools))
+	for _, p := range w.pools {
+		result = append(result, newPoolStatus(p))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func (w *statusWriter) Pool(name string) (PoolStatus, bool) {
+	w.mtx.RLock()
+	defer w.mtx.RUnlock()
+
+	p, ok := w.pools[name]
+	if !ok {
+		return PoolStatus{}, false
+	}
+	return newPoolStatus(p), true
+}
+
+func (w *statusWriter) Items(name string) ([]ItemStatus, bool) {
+	w.mtx.RLock()
+	defer w.mtx.RUnlock()
+
+	if _, ok := w.pools[name]; !ok {
+		return nil, false
+	}
+
+	result := make([]ItemStatus, 0)
+	for _, c := range w.containers {
+		if c.pname == name {
+			result = append(result, newItemStatus(c))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, true
+}
+
+func newPoolStatus(p pool) PoolStatus {
+	status := PoolStatus{
+		Name:       p.name,
+		State:      string(p.state),
+		NumItems:   p.numItems,
+		NumPending: p.numPending,
+		NumReady:   p.numReady,
+		NumOut:     p.numOut,
+		NumWaiting: p.numWaiting,
+		TargetSize: p.targetSize,
+	}
+	if p.err != nil {
+		status.Error = fmt.Sprint(p.err)
+	}
+	return status
+}
+
+func newItemStatus(c container) ItemStatus {
+	status := ItemStatus{
+		ID:        c.id,
+		Pool:      c.pname,
+		State:     string(c.state),
+		Lifecycle: c.lifecycleName,
+		Action:    c.actionName,
+		Attempt:   c.actionAttempt,
+		Attempts:  c.actionAttempts,
+		Step:      c.actionStep,
+	}
+	if c.actionDelay > 0 {
+		status.RetryIn = c.actionDelay.String()
+	}
+	if c.actionError != nil {
+		status.Error = fmt.Sprint(c.actionError)
+	}
+	return status
+}
@@ -106,6 +106,9 @@ func poolsWidget() *tablePanel {
 		{"Ready", 3},
 		{"Total", 3},
 		{"Pending", 3},
+		{"Out", 3},
+		{"Target", 3},
+		{"Waiting", 3},
 		{"Error", 0},
 	})
 	tw.SetContent(t)
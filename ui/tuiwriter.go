@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	throttle "github.com/boz/go-throttle"
@@ -241,6 +242,9 @@ func (pr poolTRow) cols() []tuiTD {
 		{strconv.Itoa(pr.value.numReady), style},
 		{strconv.Itoa(pr.value.numItems), style},
 		{strconv.Itoa(pr.value.numPending), style},
+		{strconv.Itoa(pr.value.numOut), style},
+		{strconv.Itoa(pr.value.targetSize), style},
+		{strconv.Itoa(pr.value.numWaiting), style},
 		{errval, errcolor},
 	}
 	return cols
@@ -272,7 +276,9 @@ func (cr containerTRow) cols() []tuiTD {
 	switch cr.value.state {
 	case cstateReady:
 		style = style.Foreground(tcell.ColorGreen)
-	case cstateExiting, cstateExited:
+	case cstateCheckedOut:
+		style = style.Foreground(tcell.ColorTeal)
+	case cstateExiting, cstateExited, cstateExpired:
 		style = style.Foreground(tcell.ColorRed)
 	default:
 		style = style.Foreground(tcell.ColorYellow)
@@ -287,16 +293,17 @@ func (cr containerTRow) cols() []tuiTD {
 
 	if cr.value.actionName == "" {
 		cols = append(cols, tuiTD{"", style}) // attempt
-		cols = append(cols, tuiTD{"", style}) // error
 	} else {
 		val := fmt.Sprintf("[%v/%v]", cr.value.actionAttempt, cr.value.actionAttempts)
 		cols = append(cols, tuiTD{val, style})
+	}
 
-		if cr.value.actionError == nil {
-			cols = append(cols, tuiTD{"", style})
-		} else {
-			cols = append(cols, tuiTD{fmt.Sprint(cr.value.actionError), style.Foreground(tcell.ColorWhite)})
-		}
+	if cr.value.actionError == nil {
+		cols = append(cols, tuiTD{"", style})
+	} else {
+		// only the first line fits; errors may include container logs.
+		msg := strings.SplitN(fmt.Sprint(cr.value.actionError), "\n", 2)[0]
+		cols = append(cols, tuiTD{msg, style.Foreground(tcell.ColorWhite)})
 	}
 
 	return cols
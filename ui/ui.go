@@ -4,14 +4,12 @@ import "io"
 
 type UI interface {
 	Emitter() Emitter
+	Status() Status
 	Stop()
 }
 
 func NewIOUI(w io.Writer) (UI, error) {
-	writer := newIOWriter(w)
-	processor := newProcessor(writer)
-	uie := newEmitter(processor)
-	return &processedUI{processor, uie}, nil
+	return newProcessedUI(newIOWriter(w)), nil
 }
 
 func NewTUI(donech chan bool) (UI, error) {
@@ -19,21 +17,30 @@ func NewTUI(donech chan bool) (UI, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	processor := newProcessor(writer)
-	uie := newEmitter(processor)
-	return &processedUI{processor, uie}, nil
+	return newProcessedUI(writer), nil
 }
 
 type processedUI struct {
 	processor *processor
 	uie       Emitter
+	status    Status
+}
+
+func newProcessedUI(w writer) UI {
+	status := newStatusWriter()
+	processor := newProcessor(multiWriter{w, status})
+	uie := newEmitter(processor)
+	return &processedUI{processor, uie, status}
 }
 
 func (pui *processedUI) Emitter() Emitter {
 	return pui.uie
 }
 
+func (pui *processedUI) Status() Status {
+	return pui.status
+}
+
 func (pui *processedUI) Stop() {
 	pui.processor.stop()
 }
@@ -14,6 +14,32 @@ type writer interface {
 	stop()
 }
 
+type multiWriter []writer
+
+func (mw multiWriter) updatePool(p pool) {
+	for _, w := range mw {
+		w.updatePool(p)
+	}
+}
+
+func (mw multiWriter) updateContainer(c container) {
+	for _, w := range mw {
+		w.updateContainer(c)
+	}
+}
+
+func (mw multiWriter) deleteContainer(c container) {
+	for _, w := range mw {
+		w.deleteContainer(c)
+	}
+}
+
+func (mw multiWriter) stop() {
+	for _, w := range mw {
+		w.stop()
+	}
+}
+
 type ioWriter struct {
 	w io.Writer
 }
@@ -53,7 +79,7 @@ type ioPool pool
 
 func (p ioPool) Print(w io.Writer) {
 	fmt.Fprint(w, ioPoolPrefix)
-	fmt.Fprintf(w, "%v %v items: %v ready: %v  pending: %v\n", p.name, p.state, p.numItems, p.numReady, p.numPending)
+	fmt.Fprintf(w, "%v %v items: %v ready: %v  pending: %v checked-out: %v target: %v waiting: %v\n", p.name, p.state, p.numItems, p.numReady, p.numPending, p.numOut, p.targetSize, p.numWaiting)
 }
 
 type ioContainer container
@@ -63,17 +89,26 @@ func (c ioContainer) Print(w io.Writer) {
 	fmt.Fprintf(w, "%v %v %v", c.pname, c.id[0:12], c.state)
 
 	if c.actionName == "" {
-		goto done
+		goto err
 	}
 
 	fmt.Fprintf(w, " %v [%v/%v]", c.actionName, c.actionAttempt, c.actionAttempts)
 
+	if c.actionStep != "" {
+		fmt.Fprintf(w, " %v", c.actionStep)
+	}
+
+err:
 	if c.actionError == nil {
 		goto done
 	}
 
 	fmt.Fprintf(w, " %v", c.actionError)
 
+	if c.actionDelay > 0 {
+		fmt.Fprintf(w, " (retry in %v)", c.actionDelay)
+	}
+
 done:
 	fmt.Fprintln(w)
 }